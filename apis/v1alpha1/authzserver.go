@@ -22,6 +22,47 @@ type AuthorizationServerSpec struct {
 	Type AuthorizationServerType `json:"type"`
 	// AuthorizationServerPolicySource contains all the sources of policies for the authorization server.
 	Sources []AuthorizationServerPolicySource `json:"sources,omitempty"`
+	// Defaults configures the decisions taken when policies don't produce one.
+	// +optional
+	Defaults *DefaultDecisions `json:"defaults,omitempty"`
+}
+
+// DefaultDecisions defines the decisions taken when policies don't produce one.
+type DefaultDecisions struct {
+	// NoMatch is the decision taken when no policy produced a result.
+	// Requests are allowed when not set.
+	// +optional
+	NoMatch *Decision `json:"noMatch,omitempty"`
+	// SourceError is the decision taken when policy sources failed to load and no policy produced a result.
+	// Requests are allowed when not set.
+	// +optional
+	SourceError *Decision `json:"sourceError,omitempty"`
+}
+
+// DecisionAction is the action of a decision.
+// +kubebuilder:validation:Enum=Allow;Deny
+type DecisionAction string
+
+const (
+	// DecisionActionAllow allows the request.
+	DecisionActionAllow DecisionAction = "Allow"
+	// DecisionActionDeny denies the request.
+	DecisionActionDeny DecisionAction = "Deny"
+)
+
+// Decision defines an authorization decision.
+type Decision struct {
+	// Action is the action taken, Allow or Deny.
+	Action DecisionAction `json:"action"`
+	// Status is the HTTP status code returned when the request is denied.
+	// +kubebuilder:default=403
+	// +kubebuilder:validation:Minimum=400
+	// +kubebuilder:validation:Maximum=599
+	// +optional
+	Status int `json:"status,omitempty"`
+	// Body is the response body returned when the request is denied.
+	// +optional
+	Body string `json:"body,omitempty"`
 }
 
 // AuthorizationServerType defines the type of authorization server.
@@ -43,12 +84,38 @@ type EnvoyAuthorizationServer struct {
 	Network string `json:"network"`
 	// Address is the network address the server listens on.
 	Address string `json:"address"`
+	// TLS configures TLS (or mutual TLS) on the gRPC listener.
+	// +optional
+	TLS *TLSConfig `json:"tls,omitempty"`
+}
+
+// TLSConfig defines the TLS configuration of a listener.
+// Certificate files are reloaded from disk when they change.
+type TLSConfig struct {
+	// CertFile is the path to the server certificate file.
+	CertFile string `json:"certFile"`
+	// KeyFile is the path to the server private key file.
+	KeyFile string `json:"keyFile"`
+	// ClientCAFile is the path to the CA bundle used to verify client certificates.
+	// When set, clients must present a valid certificate (mutual TLS).
+	// +optional
+	ClientCAFile string `json:"clientCAFile,omitempty"`
+	// AllowedClientSANs restricts the client certificates accepted by the server.
+	// A client certificate must contain at least one SAN (DNS name, URI, email or IP address) matching one of the patterns.
+	// Wildcards are supported, requires ClientCAFile.
+	// +optional
+	AllowedClientSANs []string `json:"allowedClientSANs,omitempty"`
 }
 
 // HTTPAuthorizationServer defines the HTTP authorization server configuration.
 type HTTPAuthorizationServer struct {
 	// Address is the network address the server listens on.
 	Address string `json:"address"`
+	// Adapter selects built-in request and response modifiers for a proxy forward auth integration,
+	// explicit modifiers take precedence.
+	// +kubebuilder:validation:Enum=nginx;traefik;caddy;oauth2-proxy
+	// +optional
+	Adapter string `json:"adapter,omitempty"`
 	// Where to find the request to authenticate, the incoming request itself or the body of it
 	NestedRequest bool `json:"nestedRequest,omitempty"`
 	// Modifiers to apply to requests and responses.
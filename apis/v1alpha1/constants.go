@@ -5,6 +5,8 @@ import (
 )
 
 const (
-	EvaluationModeEnvoy vpol.EvaluationMode = "Envoy"
-	EvaluationModeHTTP  vpol.EvaluationMode = "HTTP"
+	EvaluationModeEnvoy               vpol.EvaluationMode = "Envoy"
+	EvaluationModeExtProc             vpol.EvaluationMode = "ExtProc"
+	EvaluationModeHTTP                vpol.EvaluationMode = "HTTP"
+	EvaluationModeSubjectAccessReview vpol.EvaluationMode = "SubjectAccessReview"
 )
@@ -0,0 +1,58 @@
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,categories=kyverno
+
+// FunctionLibrary is a resource that declares CEL functions shared across policies.
+// Functions are called from policies using the `lib` variable, e.g. `lib.bearerToken(object)`.
+type FunctionLibrary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              FunctionLibrarySpec `json:"spec,omitempty"`
+}
+
+// FunctionLibrarySpec defines the spec of a function library.
+type FunctionLibrarySpec struct {
+	// Functions contains the functions declared by the library.
+	// +listType=map
+	// +listMapKey=name
+	Functions []Function `json:"functions,omitempty"`
+}
+
+// Function defines a named CEL function.
+type Function struct {
+	// Name is the name of the function.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_]*$`
+	Name string `json:"name"`
+	// Parameters contains the function parameters, in call order.
+	// +optional
+	Parameters []FunctionParameter `json:"parameters,omitempty"`
+	// Expression is the CEL expression computing the function result.
+	// Parameters are available as variables in the expression.
+	Expression string `json:"expression"`
+}
+
+// FunctionParameter defines a function parameter.
+type FunctionParameter struct {
+	// Name is the name of the parameter.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_]*$`
+	Name string `json:"name"`
+	// Type is the CEL type of the parameter, it can be a primitive type (bool, int, uint, double, string, bytes,
+	// duration, timestamp), list, map, dyn or a message type name (e.g. envoy.service.auth.v3.CheckRequest).
+	// +kubebuilder:default=dyn
+	// +optional
+	Type string `json:"type,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type FunctionLibraryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []FunctionLibrary `json:"items,omitempty"`
+}
@@ -113,6 +113,11 @@ func (in *AuthorizationServerSpec) DeepCopyInto(out *AuthorizationServerSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(DefaultDecisions)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthorizationServerSpec.
@@ -131,7 +136,7 @@ func (in *AuthorizationServerType) DeepCopyInto(out *AuthorizationServerType) {
 	if in.Envoy != nil {
 		in, out := &in.Envoy, &out.Envoy
 		*out = new(EnvoyAuthorizationServer)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.HTTP != nil {
 		in, out := &in.HTTP, &out.HTTP
@@ -150,9 +155,54 @@ func (in *AuthorizationServerType) DeepCopy() *AuthorizationServerType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Decision) DeepCopyInto(out *Decision) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Decision.
+func (in *Decision) DeepCopy() *Decision {
+	if in == nil {
+		return nil
+	}
+	out := new(Decision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultDecisions) DeepCopyInto(out *DefaultDecisions) {
+	*out = *in
+	if in.NoMatch != nil {
+		in, out := &in.NoMatch, &out.NoMatch
+		*out = new(Decision)
+		**out = **in
+	}
+	if in.SourceError != nil {
+		in, out := &in.SourceError, &out.SourceError
+		*out = new(Decision)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultDecisions.
+func (in *DefaultDecisions) DeepCopy() *DefaultDecisions {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultDecisions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoyAuthorizationServer) DeepCopyInto(out *EnvoyAuthorizationServer) {
 	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyAuthorizationServer.
@@ -180,6 +230,121 @@ func (in *FsPolicySource) DeepCopy() *FsPolicySource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Function) DeepCopyInto(out *Function) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]FunctionParameter, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Function.
+func (in *Function) DeepCopy() *Function {
+	if in == nil {
+		return nil
+	}
+	out := new(Function)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionLibrary) DeepCopyInto(out *FunctionLibrary) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionLibrary.
+func (in *FunctionLibrary) DeepCopy() *FunctionLibrary {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionLibrary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FunctionLibrary) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionLibraryList) DeepCopyInto(out *FunctionLibraryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FunctionLibrary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionLibraryList.
+func (in *FunctionLibraryList) DeepCopy() *FunctionLibraryList {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionLibraryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FunctionLibraryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionLibrarySpec) DeepCopyInto(out *FunctionLibrarySpec) {
+	*out = *in
+	if in.Functions != nil {
+		in, out := &in.Functions, &out.Functions
+		*out = make([]Function, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionLibrarySpec.
+func (in *FunctionLibrarySpec) DeepCopy() *FunctionLibrarySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionLibrarySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionParameter) DeepCopyInto(out *FunctionParameter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionParameter.
+func (in *FunctionParameter) DeepCopy() *FunctionParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitPolicySource) DeepCopyInto(out *GitPolicySource) {
 	*out = *in
@@ -289,3 +454,23 @@ func (in *PolicyObjectReference) DeepCopy() *PolicyObjectReference {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSConfig) DeepCopyInto(out *TLSConfig) {
+	*out = *in
+	if in.AllowedClientSANs != nil {
+		in, out := &in.AllowedClientSANs, &out.AllowedClientSANs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSConfig.
+func (in *TLSConfig) DeepCopy() *TLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
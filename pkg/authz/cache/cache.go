@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/documents"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/ipset"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/metrics"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Stateful is implemented by policies whose evaluation updates some state (rate limits for example),
+// decisions are not cached while such a policy is loaded.
+type Stateful interface {
+	Stateful() bool
+}
+
+// generations identifies the policies, data documents and ip sets the cached decisions were computed with.
+type generations struct {
+	policies  int64
+	documents int64
+	ipSets    int64
+}
+
+// Cache holds the decisions of a server.
+type Cache[POLICY any, OUT any] struct {
+	name    string
+	entries *lru[Key, OUT]
+	// generations is used to detect changes
+	generations atomic.Pointer[generations]
+}
+
+// New returns a decision cache, name identifies the cache in metrics.
+func New[POLICY any, OUT any](name string, config Config) *Cache[POLICY, OUT] {
+	return &Cache[POLICY, OUT]{
+		name:    name,
+		entries: newLRU[Key, OUT](config.Size, config.TTL),
+	}
+}
+
+// invalidate purges the cache when the policies, the data documents or the ip sets changed since the last call.
+func (c *Cache[POLICY, OUT]) invalidate() {
+	current := generations{
+		policies:  engine.Generation(),
+		documents: documents.Default().Generation(),
+		ipSets:    ipset.Default().Generation(),
+	}
+	previous := c.generations.Load()
+	if previous != nil && *previous == current {
+		return
+	}
+	// only one of the concurrent requests observing the change purges the cache
+	if !c.generations.CompareAndSwap(previous, &current) {
+		return
+	}
+	if previous != nil {
+		metrics.RecordDecisionCacheInvalidation(c.name)
+	}
+	c.entries.Purge()
+}
+
+// stateful returns true when one of the policies is stateful.
+func stateful[POLICY any](policies []POLICY) bool {
+	for _, policy := range policies {
+		if s, ok := any(policy).(Stateful); ok && s.Stateful() {
+			return true
+		}
+	}
+	return false
+}
+
+// WithCache wraps inner and caches its results for requests with the same key, results are cached only when
+// cacheable returns true. Requests are not cached when the policy sources failed to load or when a stateful
+// policy is loaded (the policy must be evaluated for every request).
+func WithCache[POLICY, DATA, IN, OUT any](
+	inner core.HandlerFactory[POLICY, DATA, IN, OUT],
+	cache *Cache[POLICY, OUT],
+	key KeyFunc[IN],
+	cacheable func(OUT) bool,
+) core.HandlerFactory[POLICY, DATA, IN, OUT] {
+	return func(ctx context.Context, fc core.FactoryContext[POLICY, DATA, IN]) core.Handler[IN, OUT] {
+		handler := inner(ctx, fc)
+		return core.MakeHandlerFunc(func(ctx context.Context, in IN) OUT {
+			if fc.Source.Error != nil || stateful(fc.Source.Data) {
+				metrics.RecordDecisionCacheLookup(cache.name, "bypass")
+				return handler.Handle(ctx, in)
+			}
+			cache.invalidate()
+			k, err := key(in)
+			if err != nil {
+				ctrl.LoggerFrom(ctx).Error(err, "failed to compute decision cache key")
+				metrics.RecordDecisionCacheLookup(cache.name, "bypass")
+				return handler.Handle(ctx, in)
+			}
+			if out, ok := cache.entries.Get(k); ok {
+				metrics.RecordDecisionCacheLookup(cache.name, "hit")
+				return out
+			}
+			metrics.RecordDecisionCacheLookup(cache.name, "miss")
+			out := handler.Handle(ctx, in)
+			if cacheable(out) {
+				if evicted := cache.entries.Add(k, out); evicted > 0 {
+					metrics.RecordDecisionCacheEvictions(cache.name, evicted)
+				}
+			}
+			return out
+		})
+	}
+}
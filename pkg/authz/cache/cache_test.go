@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/documents"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/ipset"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPolicy struct {
+	name     string
+	stateful bool
+}
+
+func (p *testPolicy) Stateful() bool {
+	return p.stateful
+}
+
+func TestWithCache(t *testing.T) {
+	key, err := NewKeyFunc[*httpcel.CheckRequest](v1alpha1.EvaluationModeHTTP, httpcel.RequestType, `object.attributes.method + " " + object.attributes.path`)
+	assert.NoError(t, err)
+	calls := 0
+	inner := func(ctx context.Context, fc core.FactoryContext[*testPolicy, any, *httpcel.CheckRequest]) core.Handler[*httpcel.CheckRequest, string] {
+		return core.MakeHandlerFunc(func(_ context.Context, r *httpcel.CheckRequest) string {
+			calls++
+			return r.Attributes.Path
+		})
+	}
+	cache := New[*testPolicy, string]("test", Config{Size: 10, TTL: time.Minute})
+	handler := WithCache(inner, cache, key, func(out string) bool { return out != "/error" })
+	policies := []*testPolicy{{name: "a"}}
+	handle := func(policies []*testPolicy, err error, method, path string) string {
+		fc := core.MakeFactoryContext[*testPolicy, any](core.MakeSourceContext(policies, err), nil, (*httpcel.CheckRequest)(nil))
+		r := &httpcel.CheckRequest{Attributes: httpcel.CheckRequestAttributes{Method: method, Path: path}}
+		return handler(context.Background(), fc).Handle(context.Background(), r)
+	}
+	// miss then hit
+	assert.Equal(t, "/a", handle(policies, nil, "GET", "/a"))
+	assert.Equal(t, "/a", handle(policies, nil, "GET", "/a"))
+	assert.Equal(t, 1, calls)
+	// different key
+	assert.Equal(t, "/b", handle(policies, nil, "GET", "/b"))
+	assert.Equal(t, 2, calls)
+	// results not cacheable
+	handle(policies, nil, "GET", "/error")
+	handle(policies, nil, "GET", "/error")
+	assert.Equal(t, 4, calls)
+	// source errors bypass the cache
+	handle(policies, errors.New("failed"), "GET", "/a")
+	assert.Equal(t, 5, calls)
+	// policy changes invalidate the cache
+	engine.Changed()
+	handle([]*testPolicy{{name: "a"}}, nil, "GET", "/a")
+	assert.Equal(t, 6, calls)
+	// data documents changes invalidate the cache
+	documents.Default().Store("test", map[string]any{"key": "value"})
+	handle([]*testPolicy{{name: "a"}}, nil, "GET", "/a")
+	assert.Equal(t, 7, calls)
+	assert.Equal(t, "/a", handle([]*testPolicy{{name: "a"}}, nil, "GET", "/a"))
+	assert.Equal(t, 7, calls)
+	// ip sets reloads invalidate the cache
+	ipset.Default().Store("test", ipset.New())
+	handle([]*testPolicy{{name: "a"}}, nil, "GET", "/a")
+	assert.Equal(t, 8, calls)
+	assert.Equal(t, "/a", handle([]*testPolicy{{name: "a"}}, nil, "GET", "/a"))
+	assert.Equal(t, 8, calls)
+	// stateful policies bypass the cache
+	handle([]*testPolicy{{name: "a"}, {name: "b", stateful: true}}, nil, "GET", "/a")
+	handle([]*testPolicy{{name: "a"}, {name: "b", stateful: true}}, nil, "GET", "/a")
+	assert.Equal(t, 10, calls)
+}
+
+func TestNewKeyFunc(t *testing.T) {
+	_, err := NewKeyFunc[*httpcel.CheckRequest](v1alpha1.EvaluationModeHTTP, httpcel.RequestType, "")
+	assert.Error(t, err)
+	_, err = NewKeyFunc[*httpcel.CheckRequest](v1alpha1.EvaluationModeHTTP, httpcel.RequestType, "object.attributes.contentLength")
+	assert.Error(t, err)
+	key, err := NewKeyFunc[*httpcel.CheckRequest](v1alpha1.EvaluationModeHTTP, httpcel.RequestType, `object.attributes.Header("authorization").join(",")`)
+	assert.NoError(t, err)
+	a, err := key(&httpcel.CheckRequest{Attributes: httpcel.CheckRequestAttributes{Header: map[string][]string{"Authorization": {"Bearer a"}}}})
+	assert.NoError(t, err)
+	b, err := key(&httpcel.CheckRequest{Attributes: httpcel.CheckRequestAttributes{Header: map[string][]string{"Authorization": {"Bearer b"}}}})
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, b)
+}
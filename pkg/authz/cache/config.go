@@ -0,0 +1,19 @@
+package cache
+
+import "time"
+
+// Config configures the decision cache.
+type Config struct {
+	// Size is the maximum number of cached decisions, the cache is disabled when zero
+	Size int
+	// TTL is how long a decision is cached
+	TTL time.Duration
+	// Key is the CEL expression computing the cache key of a request (available as `object`),
+	// requests with the same key get the same decision
+	Key string
+}
+
+// Enabled returns true when the cache is enabled.
+func (c Config) Enabled() bool {
+	return c.Size > 0
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	kcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+)
+
+// Key is the hash of the value returned by the key expression, the raw value can contain secrets
+// (tokens for example) and can be large, it is never stored.
+type Key [32]byte
+
+// KeyFunc computes the cache key of a request.
+type KeyFunc[IN any] func(IN) (Key, error)
+
+// NewKeyFunc compiles the key expression for the given evaluation mode, the request is available as `object`
+// and the expression must return a string or bytes.
+func NewKeyFunc[IN any](mode vpol.EvaluationMode, object *types.Type, expression string) (KeyFunc[IN], error) {
+	if expression == "" {
+		return nil, errors.New("a decision cache key expression is required")
+	}
+	base, err := kcel.NewEnv(mode)
+	if err != nil {
+		return nil, err
+	}
+	env, err := base.Extend(cel.Variable("object", object))
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if err := issues.Err(); err != nil {
+		return nil, err
+	}
+	if !ast.OutputType().IsExactType(types.StringType) && !ast.OutputType().IsExactType(types.BytesType) && !ast.OutputType().IsExactType(types.DynType) {
+		return nil, fmt.Errorf("decision cache key expression must return a string or bytes, got %s", ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return func(in IN) (Key, error) {
+		out, _, err := program.Eval(map[string]any{"object": in})
+		if err != nil {
+			return Key{}, err
+		}
+		switch value := out.(type) {
+		case types.String:
+			return sha256.Sum256([]byte(value)), nil
+		case types.Bytes:
+			return sha256.Sum256(value), nil
+		default:
+			return Key{}, fmt.Errorf("decision cache key expression must return a string or bytes, got %s", out.Type())
+		}
+	}, nil
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// lru is a size bounded cache evicting the least recently used entries, entries expire after ttl.
+type lru[K comparable, V any] struct {
+	lock  sync.Mutex
+	size  int
+	ttl   time.Duration
+	now   func() time.Time
+	order *list.List
+	items map[K]*list.Element
+}
+
+func newLRU[K comparable, V any](size int, ttl time.Duration) *lru[K, V] {
+	return &lru[K, V]{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		order: list.New(),
+		items: map[K]*list.Element{},
+	}
+}
+
+// Get returns the value stored for key, expired entries are removed.
+func (c *lru[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var zero V
+	element, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	entry := element.Value.(*entry[K, V])
+	if c.now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.items, key)
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Add stores value for key and returns the number of evicted entries.
+func (c *lru[K, V]) Add(key K, value V) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	expires := c.now().Add(c.ttl)
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*entry[K, V])
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(element)
+		return 0
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	evicted := 0
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry[K, V]).key)
+		evicted++
+	}
+	return evicted
+}
+
+// Purge removes all entries.
+func (c *lru[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.order.Init()
+	c.items = map[K]*list.Element{}
+}
+
+// Len returns the number of entries, including expired entries not removed yet.
+func (c *lru[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Now()
+	c := newLRU[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+	assert.Equal(t, 0, c.Add("a", 1))
+	assert.Equal(t, 0, c.Add("b", 2))
+	// a becomes the most recently used
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	// b is evicted
+	assert.Equal(t, 1, c.Add("c", 3))
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, c.Len())
+	// entries expire
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+	// purge
+	c.Purge()
+	assert.Equal(t, 0, c.Len())
+}
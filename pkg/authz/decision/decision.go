@@ -0,0 +1,102 @@
+package decision
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+)
+
+// Decision is the decision taken by an authorization server when policies don't produce one.
+// The zero value allows requests, it implements pflag.Value so that it can be used directly as a command line flag.
+type Decision struct {
+	// Deny is true when requests are denied
+	Deny bool
+	// Status is the http status code returned when requests are denied
+	Status int
+	// Body is the response body returned when requests are denied
+	Body string
+}
+
+var (
+	// Allow allows requests
+	Allow = Decision{}
+	// Deny denies requests with a 403 status code
+	Deny = Decision{Deny: true, Status: http.StatusForbidden}
+)
+
+// Parse parses a decision of the form allow, deny, deny:<status> or deny:<status>:<body>.
+func Parse(value string) (Decision, error) {
+	action, rest, _ := strings.Cut(value, ":")
+	switch strings.ToLower(action) {
+	case "allow":
+		if rest != "" {
+			return Decision{}, fmt.Errorf("invalid decision %q, allow doesn't accept parameters", value)
+		}
+		return Allow, nil
+	case "deny":
+		decision := Deny
+		if rest == "" {
+			return decision, nil
+		}
+		status, body, _ := strings.Cut(rest, ":")
+		code, err := strconv.Atoi(status)
+		if err != nil || code < 400 || code > 599 {
+			return Decision{}, fmt.Errorf("invalid decision %q, status must be a 4xx or 5xx code", value)
+		}
+		decision.Status = code
+		decision.Body = body
+		return decision, nil
+	default:
+		return Decision{}, fmt.Errorf("invalid decision %q, expected allow, deny, deny:<status> or deny:<status>:<body>", value)
+	}
+}
+
+// FromAPI converts an api decision, requests are allowed when nil.
+func FromAPI(in *v1alpha1.Decision) Decision {
+	if in == nil || in.Action != v1alpha1.DecisionActionDeny {
+		return Allow
+	}
+	decision := Deny
+	if in.Status != 0 {
+		decision.Status = in.Status
+	}
+	decision.Body = in.Body
+	return decision
+}
+
+// StatusCode returns the http status code of a denied request.
+func (d Decision) StatusCode() int {
+	if d.Status == 0 {
+		return http.StatusForbidden
+	}
+	return d.Status
+}
+
+func (d Decision) String() string {
+	if !d.Deny {
+		return "allow"
+	}
+	if d.Body != "" {
+		return fmt.Sprintf("deny:%d:%s", d.StatusCode(), d.Body)
+	}
+	if d.StatusCode() != http.StatusForbidden {
+		return fmt.Sprintf("deny:%d", d.Status)
+	}
+	return "deny"
+}
+
+func (d *Decision) Set(value string) error {
+	decision, err := Parse(value)
+	if err != nil {
+		return err
+	}
+	*d = decision
+	return nil
+}
+
+func (d *Decision) Type() string {
+	return "decision"
+}
@@ -0,0 +1,48 @@
+package decision
+
+import (
+	"testing"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Decision
+		wantErr bool
+	}{
+		{value: "allow", want: Allow},
+		{value: "Allow", want: Allow},
+		{value: "deny", want: Deny},
+		{value: "deny:503", want: Decision{Deny: true, Status: 503}},
+		{value: "deny:401:unauthorized: missing token", want: Decision{Deny: true, Status: 401, Body: "unauthorized: missing token"}},
+		{value: "allow:200", wantErr: true},
+		{value: "deny:200", wantErr: true},
+		{value: "deny:abc", wantErr: true},
+		{value: "reject", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			// round trip
+			again, err := Parse(got.String())
+			assert.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
+
+func TestFromAPI(t *testing.T) {
+	assert.Equal(t, Allow, FromAPI(nil))
+	assert.Equal(t, Allow, FromAPI(&v1alpha1.Decision{Action: v1alpha1.DecisionActionAllow, Status: 500}))
+	assert.Equal(t, Deny, FromAPI(&v1alpha1.Decision{Action: v1alpha1.DecisionActionDeny}))
+	assert.Equal(t, Decision{Deny: true, Status: 503, Body: "unavailable"}, FromAPI(&v1alpha1.Decision{Action: v1alpha1.DecisionActionDeny, Status: 503, Body: "unavailable"}))
+}
@@ -0,0 +1,57 @@
+package envoy
+
+import (
+	"context"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+type handlerFactory = core.HandlerFactory[engine.EnvoyPolicy, dynamic.Interface, *authv3.CheckRequest, policy.Evaluation[*authv3.CheckResponse]]
+
+// withDefaults wraps inner and returns the default decisions when policies don't produce a result,
+// sourceError is used when the policy sources failed to load, noMatch otherwise.
+func withDefaults(inner handlerFactory, noMatch, sourceError decision.Decision) handlerFactory {
+	return func(ctx context.Context, fc core.FactoryContext[engine.EnvoyPolicy, dynamic.Interface, *authv3.CheckRequest]) core.Handler[*authv3.CheckRequest, policy.Evaluation[*authv3.CheckResponse]] {
+		handler := inner(ctx, fc)
+		return core.MakeHandlerFunc(func(ctx context.Context, r *authv3.CheckRequest) policy.Evaluation[*authv3.CheckResponse] {
+			out := handler.Handle(ctx, r)
+			if out.Result != nil || out.Error != nil {
+				return out
+			}
+			if fc.Source.Error != nil {
+				ctrl.LoggerFrom(ctx).Error(fc.Source.Error, "failed to load policies, applying default decision", "decision", sourceError.String())
+				out.Result = checkResponse(sourceError)
+			} else {
+				out.Result = checkResponse(noMatch)
+			}
+			return out
+		})
+	}
+}
+
+func checkResponse(d decision.Decision) *authv3.CheckResponse {
+	if !d.Deny {
+		return &authv3.CheckResponse{
+			Status:       &status.Status{Code: int32(codes.OK)},
+			HttpResponse: &authv3.CheckResponse_OkResponse{OkResponse: &authv3.OkHttpResponse{}},
+		}
+	}
+	return &authv3.CheckResponse{
+		Status: &status.Status{Code: int32(codes.PermissionDenied)},
+		HttpResponse: &authv3.CheckResponse_DeniedResponse{
+			DeniedResponse: &authv3.DeniedHttpResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode(d.StatusCode())},
+				Body:   d.Body,
+			},
+		},
+	}
+}
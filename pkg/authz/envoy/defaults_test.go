@@ -0,0 +1,53 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"k8s.io/client-go/dynamic"
+)
+
+func TestWithDefaults(t *testing.T) {
+	noResult := func(context.Context, core.FactoryContext[engine.EnvoyPolicy, dynamic.Interface, *authv3.CheckRequest]) core.Handler[*authv3.CheckRequest, policy.Evaluation[*authv3.CheckResponse]] {
+		return core.MakeHandlerFunc(func(context.Context, *authv3.CheckRequest) policy.Evaluation[*authv3.CheckResponse] {
+			return policy.Evaluation[*authv3.CheckResponse]{}
+		})
+	}
+	unavailable := decision.Decision{Deny: true, Status: 503, Body: "unavailable"}
+	tests := []struct {
+		name       string
+		sourceErr  error
+		wantCode   codes.Code
+		wantStatus int32
+	}{{
+		name:     "no match",
+		wantCode: codes.PermissionDenied, wantStatus: 403,
+	}, {
+		name:      "source error",
+		sourceErr: errors.New("failed to load"),
+		wantCode:  codes.PermissionDenied, wantStatus: 503,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := withDefaults(noResult, decision.Deny, unavailable)
+			fc := core.MakeFactoryContext(core.MakeSourceContext[engine.EnvoyPolicy](nil, tt.sourceErr), dynamic.Interface(nil), &authv3.CheckRequest{})
+			out := factory(context.Background(), fc).Handle(context.Background(), &authv3.CheckRequest{})
+			assert.NoError(t, out.Error)
+			assert.Equal(t, int32(tt.wantCode), out.Result.Status.Code)
+			assert.Equal(t, tt.wantStatus, int32(out.Result.GetDeniedResponse().GetStatus().GetCode()))
+		})
+	}
+	// allow by default
+	fc := core.MakeFactoryContext(core.MakeSourceContext[engine.EnvoyPolicy](nil, nil), dynamic.Interface(nil), &authv3.CheckRequest{})
+	out := withDefaults(noResult, decision.Allow, decision.Allow)(context.Background(), fc).Handle(context.Background(), &authv3.CheckRequest{})
+	assert.Equal(t, int32(codes.OK), out.Result.Status.Code)
+	assert.NotNil(t, out.Result.GetOkResponse())
+}
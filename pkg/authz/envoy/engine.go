@@ -0,0 +1,55 @@
+package envoy
+
+import (
+	"context"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/cache"
+	envoycel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/envoy"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/dispatchers"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/handlers"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/resulters"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"k8s.io/client-go/dynamic"
+)
+
+type Engine = core.Engine[dynamic.Interface, *authv3.CheckRequest, policy.Evaluation[*authv3.CheckResponse]]
+
+// NewEngine builds the engine evaluating the policies of the given source,
+// policies are evaluated in sequence and the first policy producing a result wins.
+func NewEngine(config Config, source engine.EnvoySource) (Engine, error) {
+	handler := withDefaults(handlers.Handler(
+		dispatchers.Sequential(
+			policy.EvaluatorFactory[engine.EnvoyPolicy](),
+			func(ctx context.Context, fc core.FactoryContext[engine.EnvoyPolicy, dynamic.Interface, *authv3.CheckRequest]) core.Breaker[engine.EnvoyPolicy, *authv3.CheckRequest, policy.Evaluation[*authv3.CheckResponse]] {
+				return core.MakeBreakerFunc(func(_ context.Context, _ engine.EnvoyPolicy, _ *authv3.CheckRequest, out policy.Evaluation[*authv3.CheckResponse]) bool {
+					return out.Result != nil
+				})
+			},
+		),
+		func(ctx context.Context, fc core.FactoryContext[engine.EnvoyPolicy, dynamic.Interface, *authv3.CheckRequest]) core.Resulter[engine.EnvoyPolicy, *authv3.CheckRequest, policy.Evaluation[*authv3.CheckResponse], policy.Evaluation[*authv3.CheckResponse]] {
+			return resulters.NewFirst[engine.EnvoyPolicy, *authv3.CheckRequest](func(out policy.Evaluation[*authv3.CheckResponse]) bool {
+				return out.Result != nil || out.Error != nil
+			})
+		},
+	), config.NoMatch, config.SourceError)
+	// cache decisions
+	if config.Cache.Enabled() {
+		key, err := cache.NewKeyFunc[*authv3.CheckRequest](v1alpha1.EvaluationModeEnvoy, envoycel.CheckRequest, config.Cache.Key)
+		if err != nil {
+			return nil, err
+		}
+		handler = cache.WithCache(
+			handler,
+			cache.New[engine.EnvoyPolicy, policy.Evaluation[*authv3.CheckResponse]]("envoy", config.Cache),
+			key,
+			func(out policy.Evaluation[*authv3.CheckResponse]) bool {
+				return out.Error == nil
+			},
+		)
+	}
+	return core.NewEngine(source, handler), nil
+}
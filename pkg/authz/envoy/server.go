@@ -2,44 +2,40 @@ package envoy
 
 import (
 	"context"
+	"errors"
 	"net"
 
 	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/server"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/dispatchers"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/handlers"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/resulters"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 )
 
-func NewServer(network, addr string, source engine.EnvoySource, dynclient dynamic.Interface) server.ServerFunc {
+func NewServer(config Config, source engine.EnvoySource, dynclient dynamic.Interface) server.ServerFunc {
 	return func(ctx context.Context) error {
+		// configure tls
+		var opts []grpc.ServerOption
+		if config.CertFile != "" || config.KeyFile != "" {
+			tlsConfig, err := server.NewTLSConfig(config.CertFile, config.KeyFile, config.ClientCAFile, config.ClientSANs)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		} else if config.ClientCAFile != "" || len(config.ClientSANs) != 0 {
+			return errors.New("client certificate verification requires a server certificate and key")
+		}
 		// create a server
-		s := grpc.NewServer()
+		s := grpc.NewServer(opts...)
 		// build the engine
-		engine := core.NewEngine(
-			source,
-			handlers.Handler(
-				dispatchers.Sequential(
-					policy.EvaluatorFactory[engine.EnvoyPolicy](),
-					func(ctx context.Context, fc core.FactoryContext[engine.EnvoyPolicy, dynamic.Interface, *authv3.CheckRequest]) core.Breaker[engine.EnvoyPolicy, *authv3.CheckRequest, policy.Evaluation[*authv3.CheckResponse]] {
-						return core.MakeBreakerFunc(func(_ context.Context, _ engine.EnvoyPolicy, _ *authv3.CheckRequest, out policy.Evaluation[*authv3.CheckResponse]) bool {
-							return out.Result != nil
-						})
-					},
-				),
-				func(ctx context.Context, fc core.FactoryContext[engine.EnvoyPolicy, dynamic.Interface, *authv3.CheckRequest]) core.Resulter[engine.EnvoyPolicy, *authv3.CheckRequest, policy.Evaluation[*authv3.CheckResponse], policy.Evaluation[*authv3.CheckResponse]] {
-					return resulters.NewFirst[engine.EnvoyPolicy, *authv3.CheckRequest](func(out policy.Evaluation[*authv3.CheckResponse]) bool {
-						return out.Result != nil || out.Error != nil
-					})
-				},
-			),
-		)
+		engine, err := NewEngine(config, source)
+		if err != nil {
+			return err
+		}
 		// setup our authorization service
 		svc := &service{
 			engine:    engine,
@@ -47,13 +43,28 @@ func NewServer(network, addr string, source engine.EnvoySource, dynclient dynami
 		}
 		// register our authorization service
 		authv3.RegisterAuthorizationServer(s, svc)
+		// register health service
+		health := server.NewHealthServer(config.Ready, authv3.Authorization_ServiceDesc.ServiceName)
+		healthgrpc.RegisterHealthServer(s, health)
 		// register reflection service
-		reflection.Register(s)
+		if config.Reflection {
+			reflection.Register(s)
+		}
 		// create a listener
-		l, err := net.Listen(network, addr)
+		l, err := net.Listen(config.Network, config.Address)
 		if err != nil {
 			return err
 		}
+		// create a wait group
+		var group wait.Group
+		// wait all tasks in the group are over
+		defer group.Wait()
+		// create a cancellable context
+		ctx, cancel := context.WithCancel(ctx)
+		// cancel context at the end
+		defer cancel()
+		// update health status
+		group.StartWithContext(ctx, health.Run)
 		// run server
 		return server.RunGrpc(ctx, s, l)
 	}
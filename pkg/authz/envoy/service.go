@@ -5,6 +5,7 @@ import (
 	"time"
 
 	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/metrics"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
@@ -33,8 +34,8 @@ func (s *service) Check(ctx context.Context, r *authv3.CheckRequest) (*authv3.Ch
 }
 
 func (s *service) check(ctx context.Context, r *authv3.CheckRequest) (_r *authv3.CheckResponse, _err error) {
-	// invoke engine
-	response := s.engine.Handle(ctx, s.dynclient, r)
+	// invoke engine, library calls are memoized for the duration of the request
+	response := s.engine.Handle(memo.NewContext(ctx), s.dynclient, r)
 	if response.Result == nil {
 		// we didn't have a response
 		return &authv3.CheckResponse{}, response.Error
@@ -0,0 +1,21 @@
+package extproc
+
+import "github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
+
+type Config struct {
+	Network      string
+	Address      string
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	ClientSANs   []string
+	// Reflection enables the gRPC reflection service
+	Reflection bool
+	// Ready reports the readiness of the server through the gRPC health service,
+	// the server is always ready when nil
+	Ready func() bool
+	// NoMatch is the decision taken when no policy produced a result
+	NoMatch decision.Decision
+	// SourceError is the decision taken when policy sources failed to load and no policy produced a result
+	SourceError decision.Decision
+}
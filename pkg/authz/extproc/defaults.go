@@ -0,0 +1,71 @@
+package extproc
+
+import (
+	"context"
+	"net/http"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+type handlerFactory = core.HandlerFactory[engine.ExtProcPolicy, dynamic.Interface, *extprocv3.ProcessingRequest, policy.Evaluation[*extprocv3.ProcessingResponse]]
+
+// withDefaults wraps inner and returns the default decisions when policies don't produce a result,
+// sourceError is used when the policy sources failed to load (even if a policy failed), noMatch otherwise.
+// The default decisions only apply to the request headers phase, the other phases of a request that
+// was let through continue when no policy answers them.
+func withDefaults(inner handlerFactory, noMatch, sourceError decision.Decision) handlerFactory {
+	return func(ctx context.Context, fc core.FactoryContext[engine.ExtProcPolicy, dynamic.Interface, *extprocv3.ProcessingRequest]) core.Handler[*extprocv3.ProcessingRequest, policy.Evaluation[*extprocv3.ProcessingResponse]] {
+		handler := inner(ctx, fc)
+		return core.MakeHandlerFunc(func(ctx context.Context, r *extprocv3.ProcessingRequest) policy.Evaluation[*extprocv3.ProcessingResponse] {
+			out := handler.Handle(ctx, r)
+			if out.Result != nil {
+				return out
+			}
+			if extproc.Phase(r) != extproc.PhaseRequestHeaders {
+				if out.Error == nil {
+					out.Result = extproc.Continue(r)
+				}
+				return out
+			}
+			if fc.Source.Error != nil {
+				ctrl.LoggerFrom(ctx).Error(fc.Source.Error, "failed to load policies, applying default decision", "decision", sourceError.String())
+				if out.Error != nil {
+					ctrl.LoggerFrom(ctx).Error(out.Error, "policy evaluation failed")
+				}
+				out.Result, out.Error = processingResponse(sourceError, r), nil
+			} else if out.Error == nil {
+				out.Result = processingResponse(noMatch, r)
+			}
+			return out
+		})
+	}
+}
+
+// errorResponse returns an immediate 500 response, it is sent when a policy failed so that
+// the stream is not aborted.
+func errorResponse() *extprocv3.ProcessingResponse {
+	return processingResponse(decision.Decision{Deny: true, Status: http.StatusInternalServerError, Body: "policy evaluation failed"}, nil)
+}
+
+// processingResponse continues processing when d allows the request, it returns an immediate response otherwise.
+func processingResponse(d decision.Decision, r *extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+	if !d.Deny {
+		return extproc.Continue(r)
+	}
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode(d.StatusCode())},
+				Body:   []byte(d.Body),
+			},
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package extproc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/dynamic"
+)
+
+func TestWithDefaults(t *testing.T) {
+	handler := func(err error) handlerFactory {
+		return func(context.Context, core.FactoryContext[engine.ExtProcPolicy, dynamic.Interface, *extprocv3.ProcessingRequest]) core.Handler[*extprocv3.ProcessingRequest, policy.Evaluation[*extprocv3.ProcessingResponse]] {
+			return core.MakeHandlerFunc(func(context.Context, *extprocv3.ProcessingRequest) policy.Evaluation[*extprocv3.ProcessingResponse] {
+				return policy.Evaluation[*extprocv3.ProcessingResponse]{Error: err}
+			})
+		}
+	}
+	noResult := func(context.Context, core.FactoryContext[engine.ExtProcPolicy, dynamic.Interface, *extprocv3.ProcessingRequest]) core.Handler[*extprocv3.ProcessingRequest, policy.Evaluation[*extprocv3.ProcessingResponse]] {
+		return core.MakeHandlerFunc(func(context.Context, *extprocv3.ProcessingRequest) policy.Evaluation[*extprocv3.ProcessingResponse] {
+			return policy.Evaluation[*extprocv3.ProcessingResponse]{}
+		})
+	}
+	request := &extprocv3.ProcessingRequest{
+		Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{}},
+	}
+	unavailable := decision.Decision{Deny: true, Status: 503, Body: "unavailable"}
+	tests := []struct {
+		name       string
+		sourceErr  error
+		policyErr  error
+		wantStatus int32
+	}{{
+		name:       "no match",
+		wantStatus: 403,
+	}, {
+		name:       "source error",
+		sourceErr:  errors.New("failed to load"),
+		wantStatus: 503,
+	}, {
+		name:       "policy error with source error",
+		sourceErr:  errors.New("failed to load"),
+		policyErr:  errors.New("failed to evaluate"),
+		wantStatus: 503,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := withDefaults(handler(tt.policyErr), decision.Deny, unavailable)
+			fc := core.MakeFactoryContext(core.MakeSourceContext[engine.ExtProcPolicy](nil, tt.sourceErr), dynamic.Interface(nil), request)
+			out := factory(context.Background(), fc).Handle(context.Background(), request)
+			assert.NoError(t, out.Error)
+			assert.Equal(t, tt.wantStatus, int32(out.Result.GetImmediateResponse().GetStatus().GetCode()))
+		})
+	}
+	// continue by default
+	fc := core.MakeFactoryContext(core.MakeSourceContext[engine.ExtProcPolicy](nil, nil), dynamic.Interface(nil), request)
+	out := withDefaults(noResult, decision.Allow, decision.Allow)(context.Background(), fc).Handle(context.Background(), request)
+	assert.NotNil(t, out.Result.GetRequestHeaders())
+	// policy errors are kept when sources loaded
+	out = withDefaults(handler(errors.New("failed to evaluate")), decision.Allow, decision.Allow)(context.Background(), fc).Handle(context.Background(), request)
+	assert.Error(t, out.Error)
+	assert.Nil(t, out.Result)
+}
+
+func TestWithDefaultsResponsePhases(t *testing.T) {
+	// policies only answer the request headers phase
+	allowRequest := func(context.Context, core.FactoryContext[engine.ExtProcPolicy, dynamic.Interface, *extprocv3.ProcessingRequest]) core.Handler[*extprocv3.ProcessingRequest, policy.Evaluation[*extprocv3.ProcessingResponse]] {
+		return core.MakeHandlerFunc(func(_ context.Context, r *extprocv3.ProcessingRequest) policy.Evaluation[*extprocv3.ProcessingResponse] {
+			if r.GetRequestHeaders() != nil {
+				return policy.Evaluation[*extprocv3.ProcessingResponse]{Result: extproc.Continue(r)}
+			}
+			return policy.Evaluation[*extprocv3.ProcessingResponse]{}
+		})
+	}
+	requests := []*extprocv3.ProcessingRequest{
+		{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{}}},
+		{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{}}},
+		{Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{}}},
+		{Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{}}},
+	}
+	for _, sourceErr := range []error{nil, errors.New("failed to load")} {
+		for _, request := range requests {
+			fc := core.MakeFactoryContext(core.MakeSourceContext[engine.ExtProcPolicy](nil, sourceErr), dynamic.Interface(nil), request)
+			out := withDefaults(allowRequest, decision.Deny, decision.Deny)(context.Background(), fc).Handle(context.Background(), request)
+			assert.NoError(t, out.Error)
+			assert.Nil(t, out.Result.GetImmediateResponse(), extproc.Phase(request))
+			assert.Equal(t, extproc.Continue(request), out.Result)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package extproc
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/server"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/dispatchers"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/handlers"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/resulters"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+func NewServer(config Config, source engine.ExtProcSource, dynclient dynamic.Interface) server.ServerFunc {
+	return func(ctx context.Context) error {
+		// configure tls
+		var opts []grpc.ServerOption
+		if config.CertFile != "" || config.KeyFile != "" {
+			tlsConfig, err := server.NewTLSConfig(config.CertFile, config.KeyFile, config.ClientCAFile, config.ClientSANs)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		} else if config.ClientCAFile != "" || len(config.ClientSANs) != 0 {
+			return errors.New("client certificate verification requires a server certificate and key")
+		}
+		// create a server
+		s := grpc.NewServer(opts...)
+		// build the engine
+		engine := core.NewEngine(
+			source,
+			withDefaults(handlers.Handler(
+				dispatchers.Sequential(
+					policy.EvaluatorFactory[engine.ExtProcPolicy](),
+					func(ctx context.Context, fc core.FactoryContext[engine.ExtProcPolicy, dynamic.Interface, *extprocv3.ProcessingRequest]) core.Breaker[engine.ExtProcPolicy, *extprocv3.ProcessingRequest, policy.Evaluation[*extprocv3.ProcessingResponse]] {
+						return core.MakeBreakerFunc(func(_ context.Context, _ engine.ExtProcPolicy, _ *extprocv3.ProcessingRequest, out policy.Evaluation[*extprocv3.ProcessingResponse]) bool {
+							return out.Result != nil
+						})
+					},
+				),
+				func(ctx context.Context, fc core.FactoryContext[engine.ExtProcPolicy, dynamic.Interface, *extprocv3.ProcessingRequest]) core.Resulter[engine.ExtProcPolicy, *extprocv3.ProcessingRequest, policy.Evaluation[*extprocv3.ProcessingResponse], policy.Evaluation[*extprocv3.ProcessingResponse]] {
+					return resulters.NewFirst[engine.ExtProcPolicy, *extprocv3.ProcessingRequest](func(out policy.Evaluation[*extprocv3.ProcessingResponse]) bool {
+						return out.Result != nil || out.Error != nil
+					})
+				},
+			), config.NoMatch, config.SourceError),
+		)
+		// setup our processing service
+		svc := &service{
+			engine:    engine,
+			dynclient: dynclient,
+		}
+		// register our processing service
+		extprocv3.RegisterExternalProcessorServer(s, svc)
+		// register health service
+		health := server.NewHealthServer(config.Ready, extprocv3.ExternalProcessor_ServiceDesc.ServiceName)
+		healthgrpc.RegisterHealthServer(s, health)
+		// register reflection service
+		if config.Reflection {
+			reflection.Register(s)
+		}
+		// create a listener
+		l, err := net.Listen(config.Network, config.Address)
+		if err != nil {
+			return err
+		}
+		// create a wait group
+		var group wait.Group
+		// wait all tasks in the group are over
+		defer group.Wait()
+		// create a cancellable context
+		ctx, cancel := context.WithCancel(ctx)
+		// cancel context at the end
+		defer cancel()
+		// update health status
+		group.StartWithContext(ctx, health.Run)
+		// run server
+		return server.RunGrpc(ctx, s, l)
+	}
+}
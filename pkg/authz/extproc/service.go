@@ -0,0 +1,59 @@
+package extproc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+type service struct {
+	extprocv3.UnimplementedExternalProcessorServer
+	engine    core.Engine[dynamic.Interface, *extprocv3.ProcessingRequest, policy.Evaluation[*extprocv3.ProcessingResponse]]
+	dynclient dynamic.Interface
+}
+
+func (s *service) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
+	ctx := stream.Context()
+	// the stream state (request headers, values stored by policies) is available in every phase
+	state := extproc.NewState()
+	ctx = extproc.NewContext(ctx, state)
+	for {
+		r, err := stream.Recv()
+		if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+			// envoy closed the stream
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		state.Record(r)
+		// execute processing, policy errors produce an error response and don't abort the stream
+		if err := stream.Send(s.process(ctx, r)); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *service) process(ctx context.Context, r *extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+	// invoke engine, library calls are memoized for the duration of the request
+	response := s.engine.Handle(memo.NewContext(ctx), s.dynclient, r)
+	if response.Error != nil {
+		ctrl.LoggerFrom(ctx).Error(response.Error, "Process failed", "phase", extproc.Phase(r))
+		return errorResponse()
+	}
+	if response.Result == nil {
+		// we didn't have a response, continue processing unmodified
+		return extproc.Continue(r)
+	}
+	return response.Result
+}
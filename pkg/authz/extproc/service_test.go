@@ -0,0 +1,122 @@
+package extproc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/google/cel-go/common/types"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"k8s.io/client-go/dynamic"
+)
+
+type fakeStream struct {
+	grpc.ServerStream
+	requests  []*extprocv3.ProcessingRequest
+	responses []*extprocv3.ProcessingResponse
+}
+
+func (s *fakeStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeStream) Recv() (*extprocv3.ProcessingRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	r := s.requests[0]
+	s.requests = s.requests[1:]
+	return r, nil
+}
+
+func (s *fakeStream) Send(r *extprocv3.ProcessingResponse) error {
+	s.responses = append(s.responses, r)
+	return nil
+}
+
+type engineFunc func(context.Context, dynamic.Interface, *extprocv3.ProcessingRequest) policy.Evaluation[*extprocv3.ProcessingResponse]
+
+func (f engineFunc) Handle(ctx context.Context, dyn dynamic.Interface, r *extprocv3.ProcessingRequest) policy.Evaluation[*extprocv3.ProcessingResponse] {
+	return f(ctx, dyn, r)
+}
+
+var _ core.Engine[dynamic.Interface, *extprocv3.ProcessingRequest, policy.Evaluation[*extprocv3.ProcessingResponse]] = engineFunc(nil)
+
+func TestProcess(t *testing.T) {
+	var seen []map[string]string
+	svc := &service{
+		engine: engineFunc(func(ctx context.Context, _ dynamic.Interface, r *extprocv3.ProcessingRequest) policy.Evaluation[*extprocv3.ProcessingResponse] {
+			// the stream state is not added to the requests
+			assert.Empty(t, r.GetAttributes())
+			state := extproc.FromContext(ctx)
+			if extproc.Phase(r) == extproc.PhaseRequestHeaders {
+				state.Set("identity", types.String("jane"))
+			} else {
+				identity, ok := state.Get("identity")
+				assert.True(t, ok)
+				assert.Equal(t, types.String("jane"), identity)
+			}
+			seen = append(seen, state.RequestHeaders())
+			return policy.Evaluation[*extprocv3.ProcessingResponse]{}
+		}),
+	}
+	stream := &fakeStream{
+		requests: []*extprocv3.ProcessingRequest{{
+			Request: &extprocv3.ProcessingRequest_RequestHeaders{
+				RequestHeaders: &extprocv3.HttpHeaders{
+					Headers: &corev3.HeaderMap{
+						Headers: []*corev3.HeaderValue{{Key: "Authorization", RawValue: []byte("Bearer token")}},
+					},
+				},
+			},
+		}, {
+			Request: &extprocv3.ProcessingRequest_ResponseHeaders{
+				ResponseHeaders: &extprocv3.HttpHeaders{},
+			},
+		}, {
+			Request: &extprocv3.ProcessingRequest_ResponseBody{
+				ResponseBody: &extprocv3.HttpBody{Body: []byte("{}")},
+			},
+		}},
+	}
+	assert.NoError(t, svc.Process(stream))
+	// responses continue in the phase of the request
+	assert.Len(t, stream.responses, 3)
+	assert.NotNil(t, stream.responses[0].GetRequestHeaders())
+	assert.NotNil(t, stream.responses[1].GetResponseHeaders())
+	assert.NotNil(t, stream.responses[2].GetResponseBody())
+	// request headers are available in every phase
+	for _, headers := range seen {
+		assert.Equal(t, map[string]string{"authorization": "Bearer token"}, headers)
+	}
+}
+
+func TestProcessPolicyError(t *testing.T) {
+	svc := &service{
+		engine: engineFunc(func(_ context.Context, _ dynamic.Interface, r *extprocv3.ProcessingRequest) policy.Evaluation[*extprocv3.ProcessingResponse] {
+			if extproc.Phase(r) == extproc.PhaseRequestHeaders {
+				return policy.Evaluation[*extprocv3.ProcessingResponse]{Error: errors.New("failed to evaluate")}
+			}
+			return policy.Evaluation[*extprocv3.ProcessingResponse]{}
+		}),
+	}
+	stream := &fakeStream{
+		requests: []*extprocv3.ProcessingRequest{{
+			Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{}},
+		}, {
+			Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{}},
+		}},
+	}
+	// the stream is not aborted
+	assert.NoError(t, svc.Process(stream))
+	assert.Len(t, stream.responses, 2)
+	assert.Equal(t, int32(500), int32(stream.responses[0].GetImmediateResponse().GetStatus().GetCode()))
+	assert.NotNil(t, stream.responses[1].GetRequestBody())
+}
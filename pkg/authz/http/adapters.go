@@ -0,0 +1,138 @@
+package http
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Adapter holds the input and output expressions used to integrate with the forward auth
+// feature of a proxy, the input expression maps the headers set by the proxy to the original
+// request and the output expression produces the responses the proxy expects.
+type Adapter struct {
+	InputExpression  string
+	OutputExpression string
+}
+
+const (
+	// forwardedInput maps the X-Forwarded-* headers set by Traefik and Caddy
+	forwardedInput = `
+http.CheckRequest{
+	attributes: http.CheckRequestAttributes{
+		method: object.attributes.header[?"X-Forwarded-Method"].orValue([object.attributes.method])[0],
+		header: object.attributes.header,
+		host: object.attributes.header[?"X-Forwarded-Host"].orValue([object.attributes.host])[0],
+		scheme: object.attributes.header[?"X-Forwarded-Proto"].orValue([object.attributes.scheme])[0],
+		path: url(object.attributes.header[?"X-Forwarded-Uri"].orValue(["/"])[0]).getEscapedPath(),
+		query: url(object.attributes.header[?"X-Forwarded-Uri"].orValue(["/"])[0]).getQuery(),
+		protocol: object.attributes.protocol,
+		body: object.attributes.body,
+		contentLength: object.attributes.contentLength,
+	}
+}
+`
+	// originalInput maps the X-Original-* headers set by NGINX auth_request (and ingress-nginx)
+	originalInput = `
+object.attributes.header[?"X-Original-Url"].hasValue()
+	? http.CheckRequest{
+		attributes: http.CheckRequestAttributes{
+			method: object.attributes.header[?"X-Original-Method"].orValue([object.attributes.method])[0],
+			header: object.attributes.header,
+			host: url(object.attributes.header["X-Original-Url"][0]).getHostname(),
+			scheme: url(object.attributes.header["X-Original-Url"][0]).getScheme(),
+			path: url(object.attributes.header["X-Original-Url"][0]).getEscapedPath(),
+			query: url(object.attributes.header["X-Original-Url"][0]).getQuery(),
+			protocol: object.attributes.protocol,
+			body: object.attributes.body,
+			contentLength: object.attributes.contentLength,
+		}
+	}
+	: http.CheckRequest{
+		attributes: http.CheckRequestAttributes{
+			method: object.attributes.header[?"X-Original-Method"].orValue([object.attributes.method])[0],
+			header: object.attributes.header,
+			host: object.attributes.header[?"X-Original-Host"].orValue([object.attributes.host])[0],
+			scheme: object.attributes.header[?"X-Original-Proto"].orValue([object.attributes.scheme])[0],
+			path: url(object.attributes.header[?"X-Original-Uri"].orValue(["/"])[0]).getEscapedPath(),
+			query: url(object.attributes.header[?"X-Original-Uri"].orValue(["/"])[0]).getQuery(),
+			protocol: object.attributes.protocol,
+			body: object.attributes.body,
+			contentLength: object.attributes.contentLength,
+		}
+	}
+`
+	// defaultOutput returns 200 when allowed and 403 with the reason as body when denied,
+	// the status and body of denied responses can be overridden by policies
+	defaultOutput = `
+has(object.ok)
+	? httpserver.HttpResponse{
+		status: 200,
+		header: object.Headers(),
+		responseHeader: object.ResponseHeaders(),
+	}
+	: httpserver.HttpResponse{
+		status: object.denied.status != 0 ? object.denied.status : 403,
+		header: object.Headers(),
+		body: bytes(object.denied.body != "" ? object.denied.body : object.denied.reason),
+	}
+`
+	// oauth2ProxyOutput returns 202 when allowed and 401 when denied, like oauth2-proxy /oauth2/auth endpoint
+	oauth2ProxyOutput = `
+has(object.ok)
+	? httpserver.HttpResponse{
+		status: 202,
+		header: object.Headers(),
+		responseHeader: object.ResponseHeaders(),
+	}
+	: httpserver.HttpResponse{
+		status: object.denied.status != 0 ? object.denied.status : 401,
+		header: object.Headers(),
+		body: bytes(object.denied.body != "" ? object.denied.body : object.denied.reason),
+	}
+`
+)
+
+var adapters = map[string]Adapter{
+	// NGINX auth_request, only 2xx, 401 and 403 responses are supported by nginx
+	"nginx": {
+		InputExpression:  originalInput,
+		OutputExpression: defaultOutput,
+	},
+	// Traefik forwardAuth, the response of a denied request is returned to the client
+	"traefik": {
+		InputExpression:  forwardedInput,
+		OutputExpression: defaultOutput,
+	},
+	// Caddy forward_auth, the response of a denied request is returned to the client
+	"caddy": {
+		InputExpression:  forwardedInput,
+		OutputExpression: defaultOutput,
+	},
+	// oauth2-proxy style, accepts both X-Forwarded-* and X-Original-* headers
+	"oauth2-proxy": {
+		InputExpression: `
+object.attributes.header[?"X-Forwarded-Uri"].hasValue()
+	? ` + forwardedInput + `
+	: ` + originalInput,
+		OutputExpression: oauth2ProxyOutput,
+	},
+}
+
+// Adapters returns the names of the available adapters.
+func Adapters() []string {
+	var names []string
+	for name := range adapters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// GetAdapter returns the adapter with the given name.
+func GetAdapter(name string) (Adapter, error) {
+	adapter, ok := adapters[name]
+	if !ok {
+		return Adapter{}, fmt.Errorf("unknown adapter %q, valid adapters are %s", name, strings.Join(Adapters(), ", "))
+	}
+	return adapter, nil
+}
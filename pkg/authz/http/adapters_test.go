@@ -0,0 +1,109 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	kcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel"
+	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	httpserver "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/httpserver"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdapters(t *testing.T) {
+	base, err := kcel.NewEnv(v1alpha1.EvaluationModeHTTP)
+	assert.NoError(t, err)
+	tests := []struct {
+		adapter   string
+		headers   map[string]string
+		okStatus  int
+		denStatus int
+	}{{
+		adapter: "nginx",
+		headers: map[string]string{
+			"X-Original-Method": "DELETE",
+			"X-Original-Url":    "https://app.example.com/api/items?id=1",
+		},
+		okStatus:  200,
+		denStatus: 403,
+	}, {
+		adapter: "traefik",
+		headers: map[string]string{
+			"X-Forwarded-Method": "DELETE",
+			"X-Forwarded-Proto":  "https",
+			"X-Forwarded-Host":   "app.example.com",
+			"X-Forwarded-Uri":    "/api/items?id=1",
+		},
+		okStatus:  200,
+		denStatus: 403,
+	}, {
+		adapter: "caddy",
+		headers: map[string]string{
+			"X-Forwarded-Method": "DELETE",
+			"X-Forwarded-Proto":  "https",
+			"X-Forwarded-Host":   "app.example.com",
+			"X-Forwarded-Uri":    "/api/items?id=1",
+		},
+		okStatus:  200,
+		denStatus: 403,
+	}, {
+		adapter: "oauth2-proxy",
+		headers: map[string]string{
+			"X-Forwarded-Method": "DELETE",
+			"X-Forwarded-Proto":  "https",
+			"X-Forwarded-Host":   "app.example.com",
+			"X-Forwarded-Uri":    "/api/items?id=1",
+		},
+		okStatus:  202,
+		denStatus: 401,
+	}, {
+		adapter: "oauth2-proxy",
+		headers: map[string]string{
+			"X-Original-Method": "DELETE",
+			"X-Original-Url":    "https://app.example.com/api/items?id=1",
+		},
+		okStatus:  202,
+		denStatus: 401,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.adapter, func(t *testing.T) {
+			adapter, err := GetAdapter(tt.adapter)
+			assert.NoError(t, err)
+			// input
+			input, err := compileInput(base, adapter.InputExpression)
+			assert.NoError(t, err)
+			r := httptest.NewRequest("GET", "http://authz.local/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			request, err := httpcel.NewRequest(r)
+			assert.NoError(t, err)
+			out, _, err := input.Eval(map[string]any{"object": &request})
+			assert.NoError(t, err)
+			got, ok := out.Value().(*httpcel.CheckRequest)
+			assert.True(t, ok)
+			assert.Equal(t, "DELETE", got.Attributes.Method)
+			assert.Equal(t, "app.example.com", got.Attributes.Host)
+			assert.Equal(t, "https", got.Attributes.Scheme)
+			assert.Equal(t, "/api/items", got.Attributes.Path)
+			assert.Equal(t, []string{"1"}, got.Attributes.Query["id"])
+			// output
+			output, err := compileOutput(base, adapter.OutputExpression)
+			assert.NoError(t, err)
+			for response, status := range map[*httpcel.CheckResponse]int{
+				{Ok: &httpcel.CheckResponseOk{}}:                         tt.okStatus,
+				{Denied: &httpcel.CheckResponseDenied{Reason: "denied"}}: tt.denStatus,
+			} {
+				out, _, err := output.Eval(map[string]any{"object": response})
+				assert.NoError(t, err)
+				got, err := utils.ConvertToNative[httpserver.HttpResponse](out)
+				assert.NoError(t, err)
+				assert.Equal(t, status, got.Status)
+			}
+		})
+	}
+	_, err = GetAdapter("unknown")
+	assert.Error(t, err)
+}
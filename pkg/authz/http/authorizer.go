@@ -2,6 +2,7 @@ package http
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/google/cel-go/cel"
 	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
 	httpserver "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/httpserver"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/metrics"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
@@ -24,6 +26,7 @@ type authorizer struct {
 	inputProgram  cel.Program
 	outputProgram cel.Program
 	nestedRequest bool
+	maxBodySize   int64
 }
 
 func (a *authorizer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -31,6 +34,13 @@ func (a *authorizer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	logger := ctrl.LoggerFrom(r.Context()).WithValues("from", r.RemoteAddr)
 	logger.Info("received request")
+	if a.maxBodySize > 0 {
+		if r.ContentLength > a.maxBodySize {
+			writeTooLarge(w, a.maxBodySize)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, a.maxBodySize)
+	}
 	if a.nestedRequest {
 		reader := bufio.NewReader(r.Body)
 		req, err := http.ReadRequest(reader)
@@ -60,18 +70,14 @@ func (a *authorizer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	response := a.engine.Handle(r.Context(), a.dyn, &httpReq)
+	// default decisions are applied by the engine when no policy produced a result
+	response := a.engine.Handle(memo.NewContext(r.Context()), a.dyn, &httpReq)
 	if response.Error != nil {
 		metrics.RecordHTTPRequestError(r.Context(), httpReq, response.Error)
 		writeErrResp(w, response.Error)
 		return
 	}
 	result := response.Result
-	if result == nil {
-		result = &httpcel.CheckResponse{
-			Ok: &httpcel.CheckResponseOk{},
-		}
-	}
 	defer metrics.RecordHTTPRequest(r.Context(), start, httpReq, result)
 	out, _, err := a.outputProgram.Eval(map[string]any{
 		"object": result,
@@ -88,15 +94,24 @@ func (a *authorizer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func writeErrResp(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeTooLarge(w, maxBytesErr.Limit)
+		return
+	}
 	w.WriteHeader(http.StatusInternalServerError)
 	fmt.Fprint(w, err.Error()) //nolint:errcheck
 }
 
+func writeTooLarge(w http.ResponseWriter, limit int64) {
+	http.Error(w, fmt.Sprintf("request body exceeds %d bytes", limit), http.StatusRequestEntityTooLarge)
+}
+
 func writeResponse(logger logr.Logger, w http.ResponseWriter, resp httpserver.HttpResponse) {
-	if resp.Header != nil {
-		for k, v := range resp.Header {
+	for _, header := range []map[string][]string{resp.Header, resp.ResponseHeader} {
+		for k, v := range header {
 			for _, val := range v {
-				w.Header().Set(k, val)
+				w.Header().Add(k, val)
 			}
 		}
 	}
@@ -1,10 +1,57 @@
 package http
 
+import (
+	"os"
+	"time"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/cache"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
+)
+
+// Default limits of the server, they protect the server from large or slow requests
+const (
+	DefaultMaxBodySize       = 4 << 20
+	DefaultMaxHeaderBytes    = 1 << 20
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultWriteTimeout      = 30 * time.Second
+	DefaultIdleTimeout       = 5 * time.Minute
+	DefaultRetryAfter        = time.Second
+)
+
 type Config struct {
-	Address          string
-	NestedRequest    bool
+	// Network is the network to listen on (tcp or unix), defaults to tcp
+	Network string
+	Address string
+	// SocketMode is the file mode of the unix socket (0 keeps the default mode)
+	SocketMode    os.FileMode
+	NestedRequest bool
+	// H2C enables HTTP/2 over cleartext connections, with prior knowledge or upgrade from HTTP/1.1
+	H2C bool
+	// Adapter is the name of the proxy adapter providing default input and output expressions
+	Adapter          string
 	InputExpression  string
 	OutputExpression string
 	CertFile         string
 	KeyFile          string
+	// NoMatch is the decision taken when no policy produced a result
+	NoMatch decision.Decision
+	// SourceError is the decision taken when policy sources failed to load and no policy produced a result
+	SourceError decision.Decision
+	// MaxBodySize is the maximum size of request bodies in bytes, larger requests are rejected with 413 (0 means no limit)
+	MaxBodySize int64
+	// MaxHeaderBytes is the maximum size of request headers in bytes (0 means the net/http default)
+	MaxHeaderBytes    int
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// MaxConcurrentRequests is the maximum number of requests processed concurrently (0 means no limit)
+	MaxConcurrentRequests int
+	// QueueTimeout is how long a request waits for a slot before being rejected with 503
+	QueueTimeout time.Duration
+	// Cache configures the decision cache
+	Cache cache.Config
+	// RetryAfter is the delay advertised to clients in the Retry-After header of rejected requests
+	RetryAfter time.Duration
 }
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"context"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
+	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+type handlerFactory = core.HandlerFactory[engine.HTTPPolicy, dynamic.Interface, *httpcel.CheckRequest, policy.Evaluation[*httpcel.CheckResponse]]
+
+// withDefaults wraps inner and returns the default decisions when policies don't produce a result,
+// sourceError is used when the policy sources failed to load, noMatch otherwise.
+func withDefaults(inner handlerFactory, noMatch, sourceError decision.Decision) handlerFactory {
+	return func(ctx context.Context, fc core.FactoryContext[engine.HTTPPolicy, dynamic.Interface, *httpcel.CheckRequest]) core.Handler[*httpcel.CheckRequest, policy.Evaluation[*httpcel.CheckResponse]] {
+		handler := inner(ctx, fc)
+		return core.MakeHandlerFunc(func(ctx context.Context, r *httpcel.CheckRequest) policy.Evaluation[*httpcel.CheckResponse] {
+			out := handler.Handle(ctx, r)
+			if out.Result != nil || out.Error != nil {
+				return out
+			}
+			if fc.Source.Error != nil {
+				ctrl.LoggerFrom(ctx).Error(fc.Source.Error, "failed to load policies, applying default decision", "decision", sourceError.String())
+				out.Result = checkResponse(sourceError)
+			} else {
+				out.Result = checkResponse(noMatch)
+			}
+			return out
+		})
+	}
+}
+
+// checkResponse returns the check response of a decision, it is rendered by the output expression like policy responses.
+func checkResponse(d decision.Decision) *httpcel.CheckResponse {
+	if !d.Deny {
+		return &httpcel.CheckResponse{
+			Ok: &httpcel.CheckResponseOk{},
+		}
+	}
+	return &httpcel.CheckResponse{
+		Denied: &httpcel.CheckResponseDenied{
+			Reason: d.Body,
+			Status: d.StatusCode(),
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
+	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/dynamic"
+)
+
+func TestWithDefaults(t *testing.T) {
+	noResult := func(context.Context, core.FactoryContext[engine.HTTPPolicy, dynamic.Interface, *httpcel.CheckRequest]) core.Handler[*httpcel.CheckRequest, policy.Evaluation[*httpcel.CheckResponse]] {
+		return core.MakeHandlerFunc(func(context.Context, *httpcel.CheckRequest) policy.Evaluation[*httpcel.CheckResponse] {
+			return policy.Evaluation[*httpcel.CheckResponse]{}
+		})
+	}
+	unavailable := decision.Decision{Deny: true, Status: 503, Body: "unavailable"}
+	tests := []struct {
+		name       string
+		sourceErr  error
+		wantStatus int
+		wantReason string
+	}{{
+		name:       "no match",
+		wantStatus: 403,
+	}, {
+		name:       "source error",
+		sourceErr:  errors.New("failed to load"),
+		wantStatus: 503,
+		wantReason: "unavailable",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := withDefaults(noResult, decision.Deny, unavailable)
+			fc := core.MakeFactoryContext(core.MakeSourceContext[engine.HTTPPolicy](nil, tt.sourceErr), dynamic.Interface(nil), &httpcel.CheckRequest{})
+			out := factory(context.Background(), fc).Handle(context.Background(), &httpcel.CheckRequest{})
+			assert.NoError(t, out.Error)
+			assert.Nil(t, out.Result.Ok)
+			assert.Equal(t, tt.wantStatus, out.Result.Denied.Status)
+			assert.Equal(t, tt.wantReason, out.Result.Denied.Reason)
+		})
+	}
+	// allow by default
+	fc := core.MakeFactoryContext(core.MakeSourceContext[engine.HTTPPolicy](nil, nil), dynamic.Interface(nil), &httpcel.CheckRequest{})
+	out := withDefaults(noResult, decision.Allow, decision.Allow)(context.Background(), fc).Handle(context.Background(), &httpcel.CheckRequest{})
+	assert.Nil(t, out.Result.Denied)
+	assert.NotNil(t, out.Result.Ok)
+}
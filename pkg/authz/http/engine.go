@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/cache"
+	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/dispatchers"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/handlers"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/resulters"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"k8s.io/client-go/dynamic"
+)
+
+type Engine = core.Engine[dynamic.Interface, *httpcel.CheckRequest, policy.Evaluation[*httpcel.CheckResponse]]
+
+// NewEngine builds the engine evaluating the policies of the given source,
+// policies are evaluated in sequence and the first policy producing a result wins.
+func NewEngine(config Config, source engine.HTTPSource) (Engine, error) {
+	handler := withDefaults(handlers.Handler(
+		dispatchers.Sequential(
+			policy.EvaluatorFactory[engine.HTTPPolicy](),
+			func(ctx context.Context, fc core.FactoryContext[engine.HTTPPolicy, dynamic.Interface, *httpcel.CheckRequest]) core.Breaker[engine.HTTPPolicy, *httpcel.CheckRequest, policy.Evaluation[*httpcel.CheckResponse]] {
+				return core.MakeBreakerFunc(func(_ context.Context, _ engine.HTTPPolicy, _ *httpcel.CheckRequest, out policy.Evaluation[*httpcel.CheckResponse]) bool {
+					return out.Result != nil
+				})
+			},
+		),
+		func(ctx context.Context, fc core.FactoryContext[engine.HTTPPolicy, dynamic.Interface, *httpcel.CheckRequest]) core.Resulter[engine.HTTPPolicy, *httpcel.CheckRequest, policy.Evaluation[*httpcel.CheckResponse], policy.Evaluation[*httpcel.CheckResponse]] {
+			return resulters.NewFirst[engine.HTTPPolicy, *httpcel.CheckRequest](func(out policy.Evaluation[*httpcel.CheckResponse]) bool {
+				return out.Result != nil || out.Error != nil
+			})
+		},
+	), config.NoMatch, config.SourceError)
+	// cache decisions
+	if config.Cache.Enabled() {
+		key, err := cache.NewKeyFunc[*httpcel.CheckRequest](v1alpha1.EvaluationModeHTTP, httpcel.RequestType, config.Cache.Key)
+		if err != nil {
+			return nil, err
+		}
+		handler = cache.WithCache(
+			handler,
+			cache.New[engine.HTTPPolicy, policy.Evaluation[*httpcel.CheckResponse]]("http", config.Cache),
+			key,
+			func(out policy.Evaluation[*httpcel.CheckResponse]) bool {
+				return out.Error == nil
+			},
+		)
+	}
+	return core.NewEngine(source, handler), nil
+}
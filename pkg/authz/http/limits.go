@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// limitConcurrency wraps next and limits the number of requests processed concurrently, requests waiting
+// more than wait for a slot are rejected with 503 and a Retry-After header (load shedding).
+func limitConcurrency(next http.Handler, max int, wait time.Duration, retryAfter time.Duration) http.Handler {
+	if max <= 0 {
+		return next
+	}
+	slots := make(chan struct{}, max)
+	shed := func(w http.ResponseWriter) {
+		// round up, retrying early would be shed again
+		w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+		http.Error(w, "too many concurrent requests", http.StatusServiceUnavailable)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			if wait <= 0 {
+				shed(w)
+				return
+			}
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case slots <- struct{}{}:
+			case <-timer.C:
+				shed(w)
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+		defer func() { <-slots }()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitUpgradeBody wraps next and limits the body of h2c upgrade requests to max bytes, h2c reads the whole
+// body of upgrade requests in memory before the request reaches the authorizer (and its body limit).
+// Larger bodies are rejected with 413, bodies of unknown length are capped.
+func limitUpgradeBody(next http.Handler, max int64) http.Handler {
+	if max <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if httpguts.HeaderValuesContainsToken(r.Header["Upgrade"], "h2c") {
+			if r.ContentLength > max {
+				writeTooLarge(w, max)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, max)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	kcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel"
+	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/dynamic"
+)
+
+type engineFunc func(context.Context, dynamic.Interface, *httpcel.CheckRequest) policy.Evaluation[*httpcel.CheckResponse]
+
+func (f engineFunc) Handle(ctx context.Context, dyn dynamic.Interface, r *httpcel.CheckRequest) policy.Evaluation[*httpcel.CheckResponse] {
+	return f(ctx, dyn, r)
+}
+
+func TestLimitConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := limitConcurrency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}), 1, 10*time.Millisecond, 1500*time.Millisecond)
+	// occupy the only slot
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	<-started
+	// the next request is shed after waiting
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	// once released, requests are processed again
+	close(release)
+	go func() { <-started }()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMaxBodySize(t *testing.T) {
+	base, err := kcel.NewEnv(v1alpha1.EvaluationModeHTTP)
+	assert.NoError(t, err)
+	output, err := compileOutput(base, defaultOutput)
+	assert.NoError(t, err)
+	a := &authorizer{
+		engine: engineFunc(func(context.Context, dynamic.Interface, *httpcel.CheckRequest) policy.Evaluation[*httpcel.CheckResponse] {
+			return policy.Evaluation[*httpcel.CheckResponse]{Result: &httpcel.CheckResponse{Ok: &httpcel.CheckResponseOk{}}}
+		}),
+		outputProgram: output,
+		maxBodySize:   8,
+	}
+	// within the limit
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("small")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	// content length over the limit
+	w = httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("way too large")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	// unknown content length over the limit
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("way too large"))
+	r.ContentLength = -1
+	w = httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestMaxBodySizeConfig(t *testing.T) {
+	config := Config{MaxBodySize: 8}
+	a, err := newAuthorizer(config, engineFunc(func(context.Context, dynamic.Interface, *httpcel.CheckRequest) policy.Evaluation[*httpcel.CheckResponse] {
+		return policy.Evaluation[*httpcel.CheckResponse]{Result: &httpcel.CheckResponse{Ok: &httpcel.CheckResponseOk{}}}
+	}), nil)
+	assert.NoError(t, err)
+	handler := newHandler(config, a)
+	// within the limit
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("small")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	// over the limit
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("way too large"))
+	r.ContentLength = -1
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	kcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel"
+	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	httpserver "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/httpserver"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultOutput(t *testing.T) {
+	base, err := kcel.NewEnv(v1alpha1.EvaluationModeHTTP)
+	assert.NoError(t, err)
+	output, err := compileOutput(base, defaultOutput)
+	assert.NoError(t, err)
+	tests := []struct {
+		name   string
+		policy string
+		want   httpserver.HttpResponse
+	}{{
+		name:   "allowed",
+		policy: `http.Allowed().Response()`,
+		want:   httpserver.HttpResponse{Status: 200, Header: map[string][]string{}, ResponseHeader: map[string][]string{}},
+	}, {
+		name:   "allowed with headers",
+		policy: `http.Allowed().WithHeader("x-user", "jane").WithHeader("X-User", "doe").WithResponseHeader("set-cookie", "a=b").Response()`,
+		want: httpserver.HttpResponse{Status: 200, Header: map[string][]string{
+			"X-User": {"jane", "doe"},
+		}, ResponseHeader: map[string][]string{
+			"Set-Cookie": {"a=b"},
+		}},
+	}, {
+		name:   "denied",
+		policy: `http.Denied("nope").Response()`,
+		want:   httpserver.HttpResponse{Status: 403, Header: map[string][]string{}, Body: []byte("nope")},
+	}, {
+		name:   "denied with overrides",
+		policy: `http.Denied("nope").WithStatus(401).WithHeader("www-authenticate", "Bearer").WithBody("login first").Response()`,
+		want: httpserver.HttpResponse{Status: 401, Header: map[string][]string{
+			"Www-Authenticate": {"Bearer"},
+		}, Body: []byte("login first")},
+	}, {
+		name:   "challenge",
+		policy: `http.Denied("missing token").WithChallenge("Bearer realm=\"api\"").Response()`,
+		want: httpserver.HttpResponse{Status: 401, Header: map[string][]string{
+			"Www-Authenticate": {`Bearer realm="api"`},
+		}, Body: []byte("missing token")},
+	}, {
+		name:   "retry after",
+		policy: `http.Denied("slow down").WithRetryAfter(duration("1500ms")).Response()`,
+		want: httpserver.HttpResponse{Status: 429, Header: map[string][]string{
+			"Retry-After": {"2"},
+		}, Body: []byte("slow down")},
+	}, {
+		name:   "unavailable for legal reasons",
+		policy: `http.Denied("blocked").WithStatus(451).WithContentType("text/plain").Response()`,
+		want: httpserver.HttpResponse{Status: 451, Header: map[string][]string{
+			"Content-Type": {"text/plain"},
+		}, Body: []byte("blocked")},
+	}, {
+		name:   "json body",
+		policy: `http.Denied("nope").WithJsonBody({"error": dyn("nope"), "code": dyn(7)}).Response()`,
+		want: httpserver.HttpResponse{Status: 403, Header: map[string][]string{
+			"Content-Type": {"application/json"},
+		}, Body: []byte(`{"code":7,"error":"nope"}`)},
+	}, {
+		name:   "problem details",
+		policy: `http.Denied("quota exceeded").WithStatus(429).WithProblemDetails({"type": "https://example.com/quota"}).Response()`,
+		want: httpserver.HttpResponse{Status: 429, Header: map[string][]string{
+			"Content-Type": {"application/problem+json"},
+		}, Body: []byte(`{"detail":"quota exceeded","status":429,"title":"Too Many Requests","type":"https://example.com/quota"}`)},
+	}, {
+		name:   "problem details status changed",
+		policy: `http.Denied("quota exceeded").WithProblemDetails({"type": "https://example.com/quota"}).WithRetryAfter(30).WithStatus(503).Response()`,
+		want: httpserver.HttpResponse{Status: 503, Header: map[string][]string{
+			"Content-Type": {"application/problem+json"},
+			"Retry-After":  {"30"},
+		}, Body: []byte(`{"detail":"quota exceeded","status":503,"title":"Service Unavailable","type":"https://example.com/quota"}`)},
+	}, {
+		name:   "problem details challenge",
+		policy: `http.Denied("missing token").WithProblemDetails({"title": "Login required"}).WithChallenge("Bearer").Response()`,
+		want: httpserver.HttpResponse{Status: 401, Header: map[string][]string{
+			"Content-Type":     {"application/problem+json"},
+			"Www-Authenticate": {"Bearer"},
+		}, Body: []byte(`{"detail":"missing token","status":401,"title":"Login required"}`)},
+	}, {
+		name:   "redirect",
+		policy: `http.Redirect("https://login.example.com/?rd=" + object.attributes.path).Response()`,
+		want: httpserver.HttpResponse{Status: 302, Header: map[string][]string{
+			"Location": {"https://login.example.com/?rd=/app"},
+		}, Body: []byte{}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := base.Extend(cel.Variable("object", httpcel.RequestType))
+			assert.NoError(t, err)
+			ast, issues := env.Compile(tt.policy)
+			assert.NoError(t, issues.Err())
+			program, err := env.Program(ast)
+			assert.NoError(t, err)
+			result, _, err := program.Eval(map[string]any{"object": &httpcel.CheckRequest{Attributes: httpcel.CheckRequestAttributes{Path: "/app"}}})
+			assert.NoError(t, err)
+			response, err := utils.ConvertToNative[*httpcel.CheckResponse](result)
+			assert.NoError(t, err)
+			out, _, err := output.Eval(map[string]any{"object": response})
+			assert.NoError(t, err)
+			got, err := utils.ConvertToNative[httpserver.HttpResponse](out)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInvalidStatus(t *testing.T) {
+	base, err := kcel.NewEnv(v1alpha1.EvaluationModeHTTP)
+	assert.NoError(t, err)
+	for _, expression := range []string{
+		`http.Denied("nope").WithStatus(42)`,
+		`http.Redirect("/login", 403)`,
+		`http.Denied("nope").WithRetryAfter(-1)`,
+	} {
+		ast, issues := base.Compile(expression)
+		assert.NoError(t, issues.Err())
+		program, err := base.Program(ast)
+		assert.NoError(t, err)
+		_, _, err = program.Eval(map[string]any{})
+		assert.Error(t, err, expression)
+	}
+}
@@ -12,106 +12,32 @@ import (
 	httpserver "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/httpserver"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/server"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/dispatchers"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/handlers"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/resulters"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"k8s.io/client-go/dynamic"
 )
 
 func NewServer(config Config, source engine.HTTPSource, dyn dynamic.Interface) server.ServerFunc {
 	return func(ctx context.Context) error {
-		base, err := kcel.NewEnv(v1alpha1.EvaluationModeHTTP)
-		if err != nil {
-			return err
-		}
-		var inputProgram cel.Program
-		if config.InputExpression != "" {
-			// 			config.InputExpression = `
-			// http.CheckRequest{
-			// 	attributes: http.CheckRequestAttributes{
-			// 		method: object.attributes.Header("x-original-method")[0],
-			// 		header: object.attributes.header,
-			// 		host: url(object.attributes.Header("x-original-url")[0]).getHostname(),
-			// 		scheme: url(object.attributes.Header("x-original-url")[0]).getScheme(),
-			// 		path: url(object.attributes.Header("x-original-url")[0]).getEscapedPath(),
-			// 		query: url(object.attributes.Header("x-original-url")[0]).getQuery(),
-			// 		body: object.attributes.body,
-			// 		fragment: "todo",
-			// 	}
-			// }
-			// `
-			inputEnv, err := base.Extend(cel.Variable("object", httpcel.RequestType))
-			if err != nil {
-				return err
-			}
-			inputAst, issues := inputEnv.Compile(config.InputExpression)
-			if err := issues.Err(); err != nil {
-				return err
-			}
-			program, err := inputEnv.Program(inputAst)
-			if err != nil {
-				return err
-			}
-			inputProgram = program
-		}
-		if config.OutputExpression == "" {
-			config.OutputExpression = `
-has(object.ok)
-	? httpserver.HttpResponse{ status: 200 }
-	: httpserver.HttpResponse{ status: 403, body: bytes(object.denied.reason) }
-`
-		}
-		outputEnv, err := base.Extend(
-			cel.Variable("object", httpcel.ResponseType),
-			httpserver.Lib(),
-		)
+		// build the engine
+		engine, err := NewEngine(config, source)
 		if err != nil {
 			return err
 		}
-		outputAst, issues := outputEnv.Compile(config.OutputExpression)
-		if err := issues.Err(); err != nil {
-			return err
-		}
-		outputProgram, err := outputEnv.Program(outputAst)
+		// register service
+		a, err := newAuthorizer(config, engine, dyn)
 		if err != nil {
 			return err
 		}
-		// create mux
-		mux := http.NewServeMux()
-		// build the engine
-		engine := core.NewEngine(
-			source,
-			handlers.Handler(
-				dispatchers.Sequential(
-					policy.EvaluatorFactory[engine.HTTPPolicy](),
-					func(ctx context.Context, fc core.FactoryContext[engine.HTTPPolicy, dynamic.Interface, *httpcel.CheckRequest]) core.Breaker[engine.HTTPPolicy, *httpcel.CheckRequest, policy.Evaluation[*httpcel.CheckResponse]] {
-						return core.MakeBreakerFunc(func(_ context.Context, _ engine.HTTPPolicy, _ *httpcel.CheckRequest, out policy.Evaluation[*httpcel.CheckResponse]) bool {
-							return out.Result != nil
-						})
-					},
-				),
-				func(ctx context.Context, fc core.FactoryContext[engine.HTTPPolicy, dynamic.Interface, *httpcel.CheckRequest]) core.Resulter[engine.HTTPPolicy, *httpcel.CheckRequest, policy.Evaluation[*httpcel.CheckResponse], policy.Evaluation[*httpcel.CheckResponse]] {
-					return resulters.NewFirst[engine.HTTPPolicy, *httpcel.CheckRequest](func(out policy.Evaluation[*httpcel.CheckResponse]) bool {
-						return out.Result != nil || out.Error != nil
-					})
-				},
-			),
-		)
-		// register service
-		a := &authorizer{
-			engine:        engine,
-			dyn:           dyn,
-			inputProgram:  inputProgram,
-			outputProgram: outputProgram,
-			nestedRequest: config.NestedRequest,
-		}
-		mux.Handle("POST /{$}", a)
 		// create server
 		s := &http.Server{
-			Addr:    config.Address,
-			Handler: mux,
+			Addr:              config.Address,
+			Handler:           newHandler(config, a),
+			MaxHeaderBytes:    config.MaxHeaderBytes,
+			ReadTimeout:       config.ReadTimeout,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
 		}
 		// serve TLS if a certfile and a keyfile are provided
 		if config.CertFile != "" && config.KeyFile != "" {
@@ -128,7 +54,132 @@ has(object.ok)
 				},
 			}
 		}
+		// create a listener
+		network := config.Network
+		if network == "" {
+			network = "tcp"
+		}
+		l, err := server.Listen(network, config.Address, config.SocketMode)
+		if err != nil {
+			return err
+		}
 		// run server
-		return server.RunHttp(ctx, s, config.CertFile, config.KeyFile)
+		return server.ServeHttp(ctx, s, l, config.CertFile, config.KeyFile)
+	}
+}
+
+// newAuthorizer compiles the input and output expressions and builds the authorizer serving the engine decisions.
+func newAuthorizer(config Config, engine Engine, dyn dynamic.Interface) (*authorizer, error) {
+	base, err := kcel.NewEnv(v1alpha1.EvaluationModeHTTP)
+	if err != nil {
+		return nil, err
+	}
+	// apply the proxy adapter, explicit expressions take precedence
+	if config.Adapter != "" {
+		adapter, err := GetAdapter(config.Adapter)
+		if err != nil {
+			return nil, err
+		}
+		if config.InputExpression == "" {
+			config.InputExpression = adapter.InputExpression
+		}
+		if config.OutputExpression == "" {
+			config.OutputExpression = adapter.OutputExpression
+		}
+	}
+	var inputProgram cel.Program
+	if config.InputExpression != "" {
+		program, err := compileInput(base, config.InputExpression)
+		if err != nil {
+			return nil, err
+		}
+		inputProgram = program
+	}
+	if config.OutputExpression == "" {
+		config.OutputExpression = defaultOutput
+	}
+	outputProgram, err := compileOutput(base, config.OutputExpression)
+	if err != nil {
+		return nil, err
+	}
+	return &authorizer{
+		engine:        engine,
+		dyn:           dyn,
+		inputProgram:  inputProgram,
+		outputProgram: outputProgram,
+		nestedRequest: config.NestedRequest,
+		maxBodySize:   config.MaxBodySize,
+	}, nil
+}
+
+// newHandler routes requests to the authorizer, applying the concurrency limits and h2c.
+func newHandler(config Config, a http.Handler) http.Handler {
+	// create mux
+	mux := http.NewServeMux()
+	handler := limitConcurrency(a, config.MaxConcurrentRequests, config.QueueTimeout, config.RetryAfter)
+	if config.Adapter != "" {
+		// proxies forward the method (and sometimes the path) of the original request
+		mux.Handle("/", handler)
+	} else {
+		mux.Handle("POST /{$}", handler)
+	}
+	if !config.H2C {
+		return mux
+	}
+	// tls connections negotiate http/2 with alpn, h2c covers cleartext connections
+	return limitUpgradeBody(h2c.NewHandler(mux, newHTTP2Server(config)), config.MaxBodySize)
+}
+
+// default http/2 settings, see golang.org/x/net/http2
+const (
+	defaultHeaderTableSize = 4 << 10
+	minReadFrameSize       = 16 << 10
+	maxReadFrameSize       = 1<<24 - 1
+)
+
+// newHTTP2Server returns the http/2 server serving h2c connections with the limits of the server.
+// The header size limit and the timeouts of the http server apply to h2c streams as well.
+func newHTTP2Server(config Config) *http2.Server {
+	s := &http2.Server{
+		IdleTimeout: config.IdleTimeout,
+	}
+	// a connection can't open more streams than requests processed concurrently
+	if config.MaxConcurrentRequests > 0 {
+		s.MaxConcurrentStreams = uint32(config.MaxConcurrentRequests)
+	}
+	// frames and the hpack table don't need to be larger than the headers
+	if config.MaxHeaderBytes > 0 {
+		s.MaxDecoderHeaderTableSize = uint32(min(config.MaxHeaderBytes, defaultHeaderTableSize))
+		s.MaxReadFrameSize = uint32(min(max(config.MaxHeaderBytes, minReadFrameSize), maxReadFrameSize))
+	}
+	return s
+}
+
+// compileInput compiles an expression transforming the incoming request.
+func compileInput(base *cel.Env, expression string) (cel.Program, error) {
+	env, err := base.Extend(cel.Variable("object", httpcel.RequestType))
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if err := issues.Err(); err != nil {
+		return nil, err
+	}
+	return env.Program(ast)
+}
+
+// compileOutput compiles an expression producing the http response from the check response.
+func compileOutput(base *cel.Env, expression string) (cel.Program, error) {
+	env, err := base.Extend(
+		cel.Variable("object", httpcel.ResponseType),
+		httpserver.Lib(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if err := issues.Err(); err != nil {
+		return nil, err
 	}
+	return env.Program(ast)
 }
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+)
+
+func TestNewHandlerH2C(t *testing.T) {
+	var protocols []string
+	a := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protocols = append(protocols, r.Proto)
+	})
+	s := httptest.NewServer(newHandler(Config{H2C: true}, a))
+	defer s.Close()
+	// prior knowledge
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		},
+	}
+	resp, err := client.Post(s.URL, "text/plain", strings.NewReader("body"))
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// plain http/1.1 still works
+	resp, err = http.Post(s.URL, "text/plain", strings.NewReader("body"))
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"HTTP/2.0", "HTTP/1.1"}, protocols)
+}
+
+func TestNewHandlerRoutes(t *testing.T) {
+	a := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	// without adapter only POST / is accepted
+	w := httptest.NewRecorder()
+	newHandler(Config{}, a).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/path", nil))
+	assert.NotEqual(t, http.StatusOK, w.Code)
+	// with an adapter any method and path is accepted
+	w = httptest.NewRecorder()
+	newHandler(Config{Adapter: "traefik"}, a).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/path", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestNewHTTP2Server(t *testing.T) {
+	s := newHTTP2Server(Config{IdleTimeout: time.Minute})
+	assert.Equal(t, &http2.Server{IdleTimeout: time.Minute}, s)
+	s = newHTTP2Server(Config{MaxConcurrentRequests: 10, MaxHeaderBytes: DefaultMaxHeaderBytes})
+	assert.Equal(t, uint32(10), s.MaxConcurrentStreams)
+	assert.Equal(t, uint32(defaultHeaderTableSize), s.MaxDecoderHeaderTableSize)
+	assert.Equal(t, uint32(DefaultMaxHeaderBytes), s.MaxReadFrameSize)
+	s = newHTTP2Server(Config{MaxHeaderBytes: 1024})
+	assert.Equal(t, uint32(1024), s.MaxDecoderHeaderTableSize)
+	assert.Equal(t, uint32(minReadFrameSize), s.MaxReadFrameSize)
+}
+
+func TestNewHandlerH2CUpgradeBody(t *testing.T) {
+	a := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := newHandler(Config{H2C: true, MaxBodySize: 4}, a)
+	upgrade := func(body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Connection", "Upgrade, HTTP2-Settings")
+		r.Header.Set("Upgrade", "h2c")
+		r.Header.Set("HTTP2-Settings", "")
+		return r
+	}
+	// upgrade bodies are read in memory by h2c, larger bodies are rejected before
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, upgrade("too large"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	// other requests reach the authorizer limits
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too large")))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
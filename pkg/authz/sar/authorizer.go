@@ -0,0 +1,58 @@
+package sar
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// maxReviewSize is the maximum size of a SubjectAccessReview request body
+const maxReviewSize = 1 << 20
+
+type authorizer struct {
+	engine core.Engine[dynamic.Interface, *sar.CheckRequest, policy.Evaluation[*sar.CheckResponse]]
+	dyn    dynamic.Interface
+}
+
+func (a *authorizer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var review authorizationv1.SubjectAccessReview
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReviewSize)).Decode(&review); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review.Status = a.review(r, review.Spec)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		ctrl.LoggerFrom(r.Context()).Error(err, "failed to write response")
+	}
+}
+
+func (a *authorizer) review(r *http.Request, spec authorizationv1.SubjectAccessReviewSpec) authorizationv1.SubjectAccessReviewStatus {
+	logger := ctrl.LoggerFrom(r.Context()).WithValues("from", r.RemoteAddr, "user", spec.User)
+	request := sar.NewRequest(spec)
+	response := a.engine.Handle(memo.NewContext(r.Context()), a.dyn, &request)
+	if response.Error != nil {
+		logger.Error(response.Error, "review failed")
+		// the api server treats evaluation errors as no opinion
+		return authorizationv1.SubjectAccessReviewStatus{
+			EvaluationError: response.Error.Error(),
+		}
+	}
+	if response.Result == nil {
+		// no policy has an opinion
+		return authorizationv1.SubjectAccessReviewStatus{}
+	}
+	return authorizationv1.SubjectAccessReviewStatus{
+		Allowed: response.Result.Allowed,
+		// denied is only meaningful when not allowed
+		Denied: response.Result.Denied && !response.Result.Allowed,
+		Reason: response.Result.Reason,
+	}
+}
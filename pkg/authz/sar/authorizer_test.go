@@ -0,0 +1,88 @@
+package sar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+type engineFunc func(context.Context, dynamic.Interface, *sar.CheckRequest) policy.Evaluation[*sar.CheckResponse]
+
+func (f engineFunc) Handle(ctx context.Context, dyn dynamic.Interface, r *sar.CheckRequest) policy.Evaluation[*sar.CheckResponse] {
+	return f(ctx, dyn, r)
+}
+
+var _ core.Engine[dynamic.Interface, *sar.CheckRequest, policy.Evaluation[*sar.CheckResponse]] = engineFunc(nil)
+
+const review = `{
+  "apiVersion": "authorization.k8s.io/v1",
+  "kind": "SubjectAccessReview",
+  "spec": {
+    "user": "jane",
+    "groups": ["dev"],
+    "resourceAttributes": {"namespace": "default", "verb": "get", "resource": "pods"}
+  }
+}`
+
+func TestAuthorizer(t *testing.T) {
+	tests := []struct {
+		name string
+		out  policy.Evaluation[*sar.CheckResponse]
+		want authorizationv1.SubjectAccessReviewStatus
+	}{{
+		name: "no opinion",
+		want: authorizationv1.SubjectAccessReviewStatus{},
+	}, {
+		name: "allowed",
+		out:  policy.Evaluation[*sar.CheckResponse]{Result: &sar.CheckResponse{Allowed: true, Reason: "dev"}},
+		want: authorizationv1.SubjectAccessReviewStatus{Allowed: true, Reason: "dev"},
+	}, {
+		name: "denied",
+		out:  policy.Evaluation[*sar.CheckResponse]{Result: &sar.CheckResponse{Denied: true, Reason: "nope"}},
+		want: authorizationv1.SubjectAccessReviewStatus{Denied: true, Reason: "nope"},
+	}, {
+		name: "error",
+		out:  policy.Evaluation[*sar.CheckResponse]{Error: errors.New("boom")},
+		want: authorizationv1.SubjectAccessReviewStatus{EvaluationError: "boom"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen *sar.CheckRequest
+			a := &authorizer{
+				engine: engineFunc(func(_ context.Context, _ dynamic.Interface, r *sar.CheckRequest) policy.Evaluation[*sar.CheckResponse] {
+					seen = r
+					return tt.out
+				}),
+			}
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(review)))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var got authorizationv1.SubjectAccessReview
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, "SubjectAccessReview", got.Kind)
+			assert.Equal(t, tt.want, got.Status)
+			assert.Equal(t, "jane", seen.User)
+			assert.Equal(t, []string{"dev"}, seen.Groups)
+			assert.Equal(t, "pods", seen.ResourceAttributes.Resource)
+		})
+	}
+}
+
+func TestAuthorizerBadRequest(t *testing.T) {
+	a := &authorizer{}
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{")))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,9 @@
+package sar
+
+type Config struct {
+	Address      string
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	ClientSANs   []string
+}
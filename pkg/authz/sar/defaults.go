@@ -0,0 +1,28 @@
+package sar
+
+import (
+	"context"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"k8s.io/client-go/dynamic"
+)
+
+type handlerFactory = core.HandlerFactory[engine.SubjectAccessReviewPolicy, dynamic.Interface, *sar.CheckRequest, policy.Evaluation[*sar.CheckResponse]]
+
+// withSourceErrors wraps inner and reports policy sources errors when policies don't produce a result,
+// the api server receives them as evaluation errors.
+func withSourceErrors(inner handlerFactory) handlerFactory {
+	return func(ctx context.Context, fc core.FactoryContext[engine.SubjectAccessReviewPolicy, dynamic.Interface, *sar.CheckRequest]) core.Handler[*sar.CheckRequest, policy.Evaluation[*sar.CheckResponse]] {
+		handler := inner(ctx, fc)
+		return core.MakeHandlerFunc(func(ctx context.Context, r *sar.CheckRequest) policy.Evaluation[*sar.CheckResponse] {
+			out := handler.Handle(ctx, r)
+			if out.Result == nil && out.Error == nil && fc.Source.Error != nil {
+				out.Error = fc.Source.Error
+			}
+			return out
+		})
+	}
+}
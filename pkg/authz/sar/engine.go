@@ -0,0 +1,45 @@
+package sar
+
+import (
+	"context"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/dispatchers"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/handlers"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/resulters"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	"k8s.io/client-go/dynamic"
+)
+
+type Engine = core.Engine[dynamic.Interface, *sar.CheckRequest, policy.Evaluation[*sar.CheckResponse]]
+
+// NewEngine builds the engine evaluating the policies of the given source,
+// policies are evaluated in sequence and the first policy allowing or denying the request wins.
+func NewEngine(source engine.SubjectAccessReviewSource) Engine {
+	return core.NewEngine(
+		source,
+		withSourceErrors(handlers.Handler(
+			dispatchers.Sequential(
+				policy.EvaluatorFactory[engine.SubjectAccessReviewPolicy](),
+				func(ctx context.Context, fc core.FactoryContext[engine.SubjectAccessReviewPolicy, dynamic.Interface, *sar.CheckRequest]) core.Breaker[engine.SubjectAccessReviewPolicy, *sar.CheckRequest, policy.Evaluation[*sar.CheckResponse]] {
+					return core.MakeBreakerFunc(func(_ context.Context, _ engine.SubjectAccessReviewPolicy, _ *sar.CheckRequest, out policy.Evaluation[*sar.CheckResponse]) bool {
+						return hasOpinion(out)
+					})
+				},
+			),
+			func(ctx context.Context, fc core.FactoryContext[engine.SubjectAccessReviewPolicy, dynamic.Interface, *sar.CheckRequest]) core.Resulter[engine.SubjectAccessReviewPolicy, *sar.CheckRequest, policy.Evaluation[*sar.CheckResponse], policy.Evaluation[*sar.CheckResponse]] {
+				return resulters.NewFirst[engine.SubjectAccessReviewPolicy, *sar.CheckRequest](func(out policy.Evaluation[*sar.CheckResponse]) bool {
+					return hasOpinion(out) || out.Error != nil
+				})
+			},
+		)),
+	)
+}
+
+// hasOpinion returns true when a policy allowed or denied the request,
+// sar.NoOpinion() behaves like null and lets the next policies decide.
+func hasOpinion(out policy.Evaluation[*sar.CheckResponse]) bool {
+	return out.Result != nil && (out.Result.Allowed || out.Result.Denied)
+}
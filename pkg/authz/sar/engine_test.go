@@ -0,0 +1,68 @@
+package sar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/compiler"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+func compile(t *testing.T, expressions ...string) []engine.SubjectAccessReviewPolicy {
+	t.Helper()
+	c := compiler.NewCompiler[dynamic.Interface, *sar.CheckRequest, *sar.CheckResponse]()
+	var out []engine.SubjectAccessReviewPolicy
+	for _, expression := range expressions {
+		policy, errs := c.Compile(&vpol.ValidatingPolicy{
+			Spec: vpol.ValidatingPolicySpec{
+				EvaluationConfiguration: &vpol.EvaluationConfiguration{Mode: v1alpha1.EvaluationModeSubjectAccessReview},
+				Validations:             []admissionregistrationv1.Validation{{Expression: expression}},
+			},
+		})
+		assert.NoError(t, errs.ToAggregate())
+		out = append(out, policy)
+	}
+	return out
+}
+
+func TestEngine(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []string
+		want     *sar.CheckResponse
+	}{{
+		name:     "no opinion doesn't hide a later denial",
+		policies: []string{`sar.NoOpinion()`, `sar.Denied("nope")`},
+		want:     &sar.CheckResponse{Denied: true, Reason: "nope"},
+	}, {
+		name:     "null doesn't hide a later denial",
+		policies: []string{`null`, `sar.Denied("nope")`},
+		want:     &sar.CheckResponse{Denied: true, Reason: "nope"},
+	}, {
+		name:     "first opinion wins",
+		policies: []string{`sar.Allowed()`, `sar.Denied("nope")`},
+		want:     &sar.CheckResponse{Allowed: true},
+	}, {
+		name:     "no opinion",
+		policies: []string{`sar.NoOpinion()`, `sar.NoOpinion()`},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEngine(core.MakeSource(compile(t, tt.policies...)...))
+			out := e.Handle(context.Background(), nil, &sar.CheckRequest{User: "jane"})
+			assert.NoError(t, out.Error)
+			if tt.want == nil {
+				assert.False(t, hasOpinion(out))
+			} else {
+				assert.Equal(t, tt.want, out.Result)
+			}
+		})
+	}
+}
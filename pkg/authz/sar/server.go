@@ -0,0 +1,42 @@
+package sar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/server"
+	"k8s.io/client-go/dynamic"
+)
+
+func NewServer(config Config, source engine.SubjectAccessReviewSource, dyn dynamic.Interface) server.ServerFunc {
+	return func(ctx context.Context) error {
+		// build the engine
+		engine := NewEngine(source)
+		// create mux
+		mux := http.NewServeMux()
+		// register service, the api server posts reviews to the path configured in its webhook kubeconfig
+		mux.Handle("POST /", &authorizer{
+			engine: engine,
+			dyn:    dyn,
+		})
+		// create server
+		s := &http.Server{
+			Addr:    config.Address,
+			Handler: mux,
+		}
+		// configure tls
+		if config.CertFile != "" || config.KeyFile != "" {
+			tlsConfig, err := server.NewTLSConfig(config.CertFile, config.KeyFile, config.ClientCAFile, config.ClientSANs)
+			if err != nil {
+				return err
+			}
+			s.TLSConfig = tlsConfig
+		} else if config.ClientCAFile != "" || len(config.ClientSANs) != 0 {
+			return errors.New("client certificate verification requires a server certificate and key")
+		}
+		// run server
+		return server.RunHttp(ctx, s, config.CertFile, config.KeyFile)
+	}
+}
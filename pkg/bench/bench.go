@@ -0,0 +1,150 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+type Config struct {
+	// Iterations is the number of times every request is evaluated
+	Iterations int
+	// Warmup is the number of iterations run before measuring
+	Warmup int
+}
+
+// Stats holds the measurements of a benchmarked target, latencies are in nanoseconds.
+type Stats struct {
+	Name        string        `json:"name"`
+	Operations  int           `json:"operations"`
+	Errors      int           `json:"errors"`
+	Throughput  float64       `json:"throughput"`
+	P50         time.Duration `json:"p50"`
+	P99         time.Duration `json:"p99"`
+	AllocsPerOp float64       `json:"allocsPerOp"`
+	BytesPerOp  float64       `json:"bytesPerOp"`
+}
+
+// Report holds the measurements of the engine, of every policy and of every rule.
+type Report struct {
+	Engine   Stats   `json:"engine"`
+	Policies []Stats `json:"policies"`
+	Rules    []Stats `json:"rules"`
+}
+
+type Engine[IN, OUT any] = core.Engine[dynamic.Interface, IN, policy.Evaluation[OUT]]
+
+type Policy[IN, OUT any] = policy.Policy[dynamic.Interface, IN, OUT]
+
+// Run benchmarks policies against requests:
+//   - through the engine built by newEngine with all the policies (the same engine as the servers)
+//   - policy by policy
+//   - rule by rule, every rule is benchmarked in a copy of its policy (same match conditions and variables) containing only this rule
+func Run[IN, OUT any](
+	ctx context.Context,
+	config Config,
+	compiler engine.Compiler[Policy[IN, OUT]],
+	newEngine func(core.Source[Policy[IN, OUT]]) (Engine[IN, OUT], error),
+	policies []*vpol.ValidatingPolicy,
+	requests []IN,
+) (*Report, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no requests to evaluate")
+	}
+	var report Report
+	var compiled []Policy[IN, OUT]
+	for _, p := range policies {
+		c, errs := compiler.Compile(p)
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("failed to compile policy %s: %w", p.Name, errs.ToAggregate())
+		}
+		compiled = append(compiled, c)
+		report.Policies = append(report.Policies, measure(p.Name, config, requests, func(r IN) error {
+			_, err := c.Evaluate(memo.NewContext(ctx), nil, r)
+			return err
+		}))
+		for i := range p.Spec.Validations {
+			rule := p.DeepCopy()
+			rule.Spec.Validations = []admissionregistrationv1.Validation{p.Spec.Validations[i]}
+			c, errs := compiler.Compile(rule)
+			if len(errs) > 0 {
+				return nil, fmt.Errorf("failed to compile policy %s: %w", p.Name, errs.ToAggregate())
+			}
+			report.Rules = append(report.Rules, measure(fmt.Sprintf("%s/validations[%d]", p.Name, i), config, requests, func(r IN) error {
+				_, err := c.Evaluate(memo.NewContext(ctx), nil, r)
+				return err
+			}))
+		}
+	}
+	engine, err := newEngine(core.MakeSource(compiled...))
+	if err != nil {
+		return nil, err
+	}
+	report.Engine = measure("engine", config, requests, func(r IN) error {
+		return engine.Handle(memo.NewContext(ctx), nil, r).Error
+	})
+	return &report, nil
+}
+
+// measure evaluates every request with fn, config.Iterations times, and reports latencies and allocations.
+// Allocations are measured over all the iterations, benchmarks must not run concurrently.
+func measure[IN any](name string, config Config, requests []IN, fn func(IN) error) Stats {
+	for range config.Warmup {
+		for _, r := range requests {
+			_ = fn(r)
+		}
+	}
+	latencies := make([]time.Duration, 0, config.Iterations*len(requests))
+	errors := 0
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for range config.Iterations {
+		for _, r := range requests {
+			t := time.Now()
+			if err := fn(r); err != nil {
+				errors++
+			}
+			latencies = append(latencies, time.Since(t))
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	stats := Stats{
+		Name:       name,
+		Operations: len(latencies),
+		Errors:     errors,
+	}
+	if len(latencies) == 0 {
+		return stats
+	}
+	slices.Sort(latencies)
+	ops := float64(len(latencies))
+	stats.Throughput = ops / elapsed.Seconds()
+	stats.P50 = percentile(latencies, 0.50)
+	stats.P99 = percentile(latencies, 0.99)
+	stats.AllocsPerOp = float64(after.Mallocs-before.Mallocs) / ops
+	stats.BytesPerOp = float64(after.TotalAlloc-before.TotalAlloc) / ops
+	return stats
+}
+
+// percentile returns the q percentile of sorted latencies (nearest rank).
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	rank := int(math.Ceil(q * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
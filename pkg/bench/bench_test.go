@@ -0,0 +1,107 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/envoy"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	vpolcompiler "github.com/kyverno/kyverno-envoy-plugin/pkg/engine/compiler"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+func TestRun(t *testing.T) {
+	policies := []*vpol.ValidatingPolicy{{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+		Spec: vpol.ValidatingPolicySpec{
+			EvaluationConfiguration: &vpol.EvaluationConfiguration{Mode: v1alpha1.EvaluationModeEnvoy},
+			Validations: []admissionregistrationv1.Validation{{
+				Expression: `object.attributes.request.http.method == "GET" ? envoy.Allowed().Response() : null`,
+			}, {
+				Expression: `envoy.Denied(403).Response()`,
+			}},
+		},
+	}}
+	docs, err := LoadDocuments(fstest.MapFS{
+		"requests.yaml": &fstest.MapFile{Data: []byte(`
+attributes:
+  request:
+    http:
+      method: GET
+      path: /
+---
+attributes:
+  request:
+    http:
+      method: POST
+      path: /
+`)},
+	}, ".")
+	assert.NoError(t, err)
+	requests, err := EnvoyRequests(docs)
+	assert.NoError(t, err)
+	assert.Len(t, requests, 2)
+	report, err := Run(
+		context.Background(),
+		Config{Iterations: 5, Warmup: 1},
+		vpolcompiler.NewCompiler[dynamic.Interface, *authv3.CheckRequest, *authv3.CheckResponse](),
+		func(source core.Source[engine.EnvoyPolicy]) (envoy.Engine, error) {
+			return envoy.NewEngine(envoy.Config{}, source)
+		},
+		policies,
+		requests,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "engine", report.Engine.Name)
+	assert.Equal(t, 10, report.Engine.Operations)
+	assert.Equal(t, 0, report.Engine.Errors)
+	assert.Len(t, report.Policies, 1)
+	assert.Equal(t, "policy", report.Policies[0].Name)
+	assert.Len(t, report.Rules, 2)
+	assert.Equal(t, "policy/validations[0]", report.Rules[0].Name)
+	assert.Equal(t, "policy/validations[1]", report.Rules[1].Name)
+	var out bytes.Buffer
+	assert.NoError(t, report.WriteJSON(&out))
+	var decoded Report
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, report.Engine.Operations, decoded.Engine.Operations)
+	out.Reset()
+	assert.NoError(t, report.WriteTable(&out))
+	assert.Contains(t, out.String(), "policy/validations[1]")
+}
+
+func TestRunNoRequests(t *testing.T) {
+	_, err := Run(
+		context.Background(),
+		Config{Iterations: 1},
+		vpolcompiler.NewCompiler[dynamic.Interface, *authv3.CheckRequest, *authv3.CheckResponse](),
+		func(source core.Source[engine.EnvoyPolicy]) (envoy.Engine, error) {
+			return envoy.NewEngine(envoy.Config{}, source)
+		},
+		nil,
+		nil,
+	)
+	assert.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i))
+	}
+	assert.Equal(t, time.Duration(50), percentile(latencies, 0.50))
+	assert.Equal(t, time.Duration(99), percentile(latencies, 0.99))
+	assert.Equal(t, time.Duration(1), percentile(latencies, 0))
+	assert.Equal(t, time.Duration(7), percentile([]time.Duration{7}, 0.99))
+}
@@ -0,0 +1,41 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteJSON writes the report as json.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteTable writes the report as a human readable table.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "NAME\tOPS\tERRORS\tOPS/S\tP50\tP99\tALLOCS/OP\tBYTES/OP"); err != nil {
+		return err
+	}
+	rows := append([]Stats{r.Engine}, r.Policies...)
+	rows = append(rows, r.Rules...)
+	for _, stats := range rows {
+		if _, err := fmt.Fprintf(tw, "%s\t%d\t%d\t%.0f\t%s\t%s\t%.1f\t%.0f\n",
+			stats.Name,
+			stats.Operations,
+			stats.Errors,
+			stats.Throughput,
+			stats.P50.Round(time.Microsecond/10),
+			stats.P99.Round(time.Microsecond/10),
+			stats.AllocsPerOp,
+			stats.BytesPerOp,
+		); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,109 @@
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/http"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/pkg/ext/file"
+	"github.com/kyverno/pkg/ext/yaml"
+	"google.golang.org/protobuf/encoding/protojson"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// HTTPRequest describes a request sent to the HTTP authz server.
+type HTTPRequest struct {
+	Method string              `json:"method"`
+	URL    string              `json:"url"`
+	Header map[string][]string `json:"header"`
+	Body   string              `json:"body"`
+}
+
+// LoadDocuments returns the json documents found in the yaml and json files of f under root (a directory or a file),
+// yaml files can contain multiple documents.
+func LoadDocuments(f fs.FS, root string) ([][]byte, error) {
+	var out [][]byte
+	err := fs.WalkDir(f, root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		switch {
+		case file.IsYaml(entry.Name()):
+			content, err := fs.ReadFile(f, path)
+			if err != nil {
+				return fmt.Errorf("failed to read file %s: %w", path, err)
+			}
+			documents, err := yaml.SplitDocuments(content)
+			if err != nil {
+				return fmt.Errorf("failed to split documents: %w", err)
+			}
+			for _, document := range documents {
+				if len(bytes.TrimSpace(document)) == 0 {
+					continue
+				}
+				document, err := k8syaml.YAMLToJSON(document)
+				if err != nil {
+					return fmt.Errorf("failed to convert %s to json: %w", path, err)
+				}
+				out = append(out, document)
+			}
+		case file.IsJson(entry.Name()):
+			content, err := fs.ReadFile(f, path)
+			if err != nil {
+				return fmt.Errorf("failed to read file %s: %w", path, err)
+			}
+			out = append(out, content)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// EnvoyRequests decodes envoy CheckRequest documents (protojson).
+func EnvoyRequests(documents [][]byte) ([]*authv3.CheckRequest, error) {
+	out := make([]*authv3.CheckRequest, 0, len(documents))
+	for _, document := range documents {
+		var request authv3.CheckRequest
+		if err := protojson.Unmarshal(document, &request); err != nil {
+			return nil, fmt.Errorf("failed to decode CheckRequest: %w", err)
+		}
+		out = append(out, &request)
+	}
+	return out, nil
+}
+
+// HTTPRequests decodes HTTPRequest documents, requests are converted the same way the HTTP authz server converts them.
+func HTTPRequests(documents [][]byte) ([]*httpcel.CheckRequest, error) {
+	out := make([]*httpcel.CheckRequest, 0, len(documents))
+	for _, document := range documents {
+		var request HTTPRequest
+		if err := k8syaml.UnmarshalStrict(document, &request); err != nil {
+			return nil, fmt.Errorf("failed to decode request: %w", err)
+		}
+		method := request.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		r, err := http.NewRequest(method, request.URL, bytes.NewBufferString(request.Body))
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range request.Header {
+			for _, value := range values {
+				r.Header.Add(name, value)
+			}
+		}
+		checkRequest, err := httpcel.NewRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &checkRequest)
+	}
+	return out, nil
+}
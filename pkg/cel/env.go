@@ -8,10 +8,15 @@ import (
 	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
 	impl "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/impl"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/envoy"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
 	httpauth "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/ipset"
 	jsoncel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/json"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/jwt"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/mcp"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/ratelimit"
 	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
 	"github.com/kyverno/kyverno/pkg/cel/libs/http"
 	"github.com/kyverno/kyverno/pkg/cel/libs/image"
@@ -60,24 +65,41 @@ func NewEnv(evalMode vpol.EvaluationMode) (*cel.Env, error) {
 		base, err = base.Extend(
 			envoy.Lib(),
 		)
+	case v1alpha1.EvaluationModeExtProc:
+		base, err = base.Extend(
+			extproc.Lib(),
+		)
 	case v1alpha1.EvaluationModeHTTP:
 		base, err = base.Extend(
 			httpauth.Lib(),
 		)
+	case v1alpha1.EvaluationModeSubjectAccessReview:
+		base, err = base.Extend(
+			sar.Lib(),
+		)
 	default:
 		err = fmt.Errorf("invalid evaluation mode passed for env builder")
 	}
 	if err != nil {
 		return nil, err
 	}
-	// create new cel env
-	return base.Extend(
+	// register extension libs
+	base, err = base.Extend(
 		http.Lib(),
+		ipset.Lib(&impl.IPSetImpl{}),
 		jwt.Lib(),
 		jsoncel.Lib(&impl.JsonImpl{}),
 		mcp.Lib(&impl.MCPImpl{}),
+		ratelimit.Lib(&impl.RateLimitImpl{}),
 		resource.Lib(),
 		image.Lib(),
 		imagedata.Lib(),
 	)
+	if err != nil {
+		return nil, err
+	}
+	// register shared functions last, their bodies can use all the other libs
+	return base.Extend(
+		functions.Lib(functions.Default().Functions()...),
+	)
 }
@@ -0,0 +1,34 @@
+package impl
+
+import (
+	"net"
+	"net/netip"
+
+	ipsetcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/ipset"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/ipset"
+)
+
+type IPSetImpl struct {
+	ipsetcel.IPSetImpl
+	Registry *ipset.Registry
+}
+
+func (i *IPSetImpl) Contains(name string, ip string) (bool, error) {
+	registry := i.Registry
+	if registry == nil {
+		registry = ipset.Default()
+	}
+	set, err := registry.Get(name)
+	if err != nil {
+		return false, err
+	}
+	// accept host:port as found in envoy socket addresses or remote addresses
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, err
+	}
+	return set.Contains(addr), nil
+}
@@ -1,6 +1,8 @@
 package impl
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -13,14 +15,122 @@ type MCPImpl struct {
 }
 
 func (m *MCPImpl) Parse(content []byte) (*mcpcel.MCPRequest, error) {
+	messages, err := m.ParseBatch(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) != 1 {
+		return nil, fmt.Errorf("expected a single JSON-RPC message, got %d (use ParseBatch for batches)", len(messages))
+	}
+	return messages[0], nil
+}
+
+// ParseBatch parses a JSON-RPC message, a JSON-RPC batch or a stream of
+// server-sent events (as used by the streamable HTTP transport) carrying
+// one or more JSON-RPC messages
+func (m *MCPImpl) ParseBatch(content []byte) ([]*mcpcel.MCPRequest, error) {
+	var out []*mcpcel.MCPRequest
+	for _, payload := range splitFrames(content) {
+		payload = bytes.TrimSpace(payload)
+		if len(payload) == 0 {
+			continue
+		}
+		// a batch is a JSON array of messages
+		if payload[0] == '[' {
+			var batch []json.RawMessage
+			if err := json.Unmarshal(payload, &batch); err != nil {
+				return nil, err
+			}
+			if len(batch) == 0 {
+				return nil, fmt.Errorf("invalid JSON-RPC batch: empty array")
+			}
+			for _, item := range batch {
+				message, err := parseMessage(item)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, message)
+			}
+			continue
+		}
+		message, err := parseMessage(payload)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, message)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no JSON-RPC message found")
+	}
+	return out, nil
+}
+
+// splitFrames returns the JSON payloads contained in the body, if the body is
+// not framed as server-sent events it is returned as is
+func splitFrames(content []byte) [][]byte {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		return [][]byte{trimmed}
+	}
+	var frames [][]byte
+	var data [][]byte
+	flush := func() {
+		if len(data) != 0 {
+			frames = append(frames, bytes.Join(data, []byte("\n")))
+			data = nil
+		}
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))
+		// an empty line dispatches the event
+		if len(line) == 0 {
+			flush()
+			continue
+		}
+		if value, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			data = append(data, bytes.TrimPrefix(value, []byte(" ")))
+		}
+		// other fields (event, id, retry) and comments are ignored
+	}
+	flush()
+	return frames
+}
+
+func parseMessage(content []byte) (*mcpcel.MCPRequest, error) {
+	var envelope struct {
+		ID     json.RawMessage          `json:"id"`
+		Method string                   `json:"method"`
+		Result json.RawMessage          `json:"result"`
+		Error  *mcp.JSONRPCErrorDetails `json:"error"`
+	}
+	if err := json.Unmarshal(content, &envelope); err != nil {
+		return nil, err
+	}
 	var jsonRPCReq mcp.JSONRPCRequest
 	if err := json.Unmarshal(content, &jsonRPCReq); err != nil {
 		return nil, err
 	}
 
 	mcpReq := &mcpcel.MCPRequest{
-		Method: jsonRPCReq.Method,
-		ID:     jsonRPCReq.ID,
+		Method:         jsonRPCReq.Method,
+		ID:             jsonRPCReq.ID,
+		IsNotification: envelope.Method != "" && (len(envelope.ID) == 0 || string(envelope.ID) == "null"),
+	}
+
+	if envelope.Method == "" {
+		if envelope.Result == nil && envelope.Error == nil {
+			return nil, fmt.Errorf("invalid JSON-RPC message: missing method, result or error")
+		}
+		mcpReq.IsResponse = true
+		mcpReq.Error = envelope.Error
+		if envelope.Result != nil {
+			if err := json.Unmarshal(envelope.Result, &mcpReq.Result); err != nil {
+				return nil, err
+			}
+		}
+		return mcpReq, nil
 	}
 
 	switch mcpReq.Method {
@@ -57,7 +167,6 @@ func (m *MCPImpl) Parse(content []byte) (*mcpcel.MCPRequest, error) {
 		if err != nil {
 			return nil, err
 		}
-		fmt.Println("mcp.MethodToolsCall params", params)
 		mcpReq.ToolCall = params
 
 	case string(mcp.MethodSetLogLevel):
@@ -0,0 +1,87 @@
+package impl
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMCPImpl_ParseBatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		wantMethods   []string
+		notifications []bool
+		responses     []bool
+		wantErr       bool
+	}{{
+		name:          "single request",
+		content:       `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"search","arguments":{"q":"foo"}}}`,
+		wantMethods:   []string{"tools/call"},
+		notifications: []bool{false},
+		responses:     []bool{false},
+	}, {
+		name:          "batch",
+		content:       `[{"jsonrpc":"2.0","id":1,"method":"tools/list"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`,
+		wantMethods:   []string{"tools/list", "notifications/initialized"},
+		notifications: []bool{false, true},
+		responses:     []bool{false, false},
+	}, {
+		name:          "response",
+		content:       `{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`,
+		wantMethods:   []string{""},
+		notifications: []bool{false},
+		responses:     []bool{true},
+	}, {
+		name:    "server-sent events",
+		content: "event: message\nid: 1\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"ping\"}\n\n: keep-alive\n\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\n",
+		wantErr: true,
+	}, {
+		name:          "server-sent events with multiple events",
+		content:       "event: message\r\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"ping\"}\r\n\r\ndata: {\"jsonrpc\":\"2.0\",\r\ndata: \"method\":\"notifications/progress\"}\r\n\r\n",
+		wantMethods:   []string{"ping", "notifications/progress"},
+		notifications: []bool{false, true},
+		responses:     []bool{false, false},
+	}, {
+		name:    "empty batch",
+		content: `[]`,
+		wantErr: true,
+	}, {
+		name:    "empty body",
+		content: ``,
+		wantErr: true,
+	}, {
+		name:    "not a JSON-RPC message",
+		content: `{"jsonrpc":"2.0","id":1}`,
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impl := &MCPImpl{}
+			got, err := impl.ParseBatch([]byte(tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, got, len(tt.wantMethods))
+			for i := range got {
+				assert.Equal(t, tt.wantMethods[i], got[i].Method)
+				assert.Equal(t, tt.notifications[i], got[i].IsNotification)
+				assert.Equal(t, tt.responses[i], got[i].IsResponse)
+			}
+		})
+	}
+}
+
+func TestMCPImpl_Parse(t *testing.T) {
+	impl := &MCPImpl{}
+	got, err := impl.Parse([]byte(`{"jsonrpc":"2.0","id":"abc","method":"tools/call","params":{"name":"search"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, string(mcp.MethodToolsCall), got.Method)
+	assert.Equal(t, "search", got.ToolCall.Name)
+	assert.Equal(t, "abc", got.ID.Value())
+	_, err = impl.Parse([]byte(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":2,"method":"ping"}]`))
+	assert.Error(t, err)
+}
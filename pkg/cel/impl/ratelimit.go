@@ -0,0 +1,34 @@
+package impl
+
+import (
+	"math"
+	"time"
+
+	ratelimitcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/ratelimit"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/ratelimit"
+)
+
+type RateLimitImpl struct {
+	ratelimitcel.RateLimitImpl
+	Store ratelimit.Store
+}
+
+func (r *RateLimitImpl) Allow(key string, limit int64, period time.Duration, burst int64) ratelimitcel.Decision {
+	store := r.Store
+	if store == nil {
+		store = ratelimit.Default()
+	}
+	result := store.Allow(key, limit, period, burst)
+	return ratelimitcel.Decision{
+		Allowed:    result.Allowed,
+		Limit:      result.Limit,
+		Remaining:  result.Remaining,
+		RetryAfter: seconds(result.RetryAfter),
+		Reset:      seconds(result.Reset),
+	}
+}
+
+// seconds rounds a duration up to the next second, as expected by the Retry-After header
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
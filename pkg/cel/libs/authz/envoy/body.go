@@ -0,0 +1,66 @@
+package envoy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+)
+
+// PartialBodyHeader is the header set by envoy when the request body was buffered,
+// it is "true" when the body was truncated to max_request_bytes
+const PartialBodyHeader = "x-envoy-auth-partial-body"
+
+// Body returns the request body, whether envoy sent it in `body` or in `raw_body` (pack_as_bytes).
+func Body(r *authv3.CheckRequest) []byte {
+	http := r.GetAttributes().GetRequest().GetHttp()
+	if raw := http.GetRawBody(); len(raw) != 0 {
+		return raw
+	}
+	return []byte(http.GetBody())
+}
+
+// IsPartialBody returns true when the request body sent by envoy is truncated.
+func IsPartialBody(r *authv3.CheckRequest) bool {
+	if strings.EqualFold(header(r, PartialBodyHeader), "true") {
+		return true
+	}
+	body := Body(r)
+	size := r.GetAttributes().GetRequest().GetHttp().GetSize()
+	// size is -1 when unknown, an empty body means envoy didn't send it
+	return len(body) != 0 && size > 0 && int64(len(body)) < size
+}
+
+// RequireBody returns the complete request body, it fails when the body was truncated
+// or when envoy was not configured to send it (including chunked requests of unknown size).
+func RequireBody(r *authv3.CheckRequest) ([]byte, error) {
+	body := Body(r)
+	size := r.GetAttributes().GetRequest().GetHttp().GetSize()
+	// envoy sets the partial body header whenever it buffers the body, without it an
+	// empty body of unknown size (chunked requests) means the body was not sent
+	if len(body) == 0 && size != 0 && header(r, PartialBodyHeader) == "" {
+		return nil, errors.New("request body is required but was not sent by envoy, check the with_request_body setting of the ext_authz filter")
+	}
+	if IsPartialBody(r) {
+		return nil, fmt.Errorf("request body is required but was truncated by envoy (received %d bytes, request size is %d), check max_request_bytes and allow_partial_message in the ext_authz filter", len(body), size)
+	}
+	return body, nil
+}
+
+// header returns the value of a request header, looking up both headers and header_map.
+func header(r *authv3.CheckRequest, name string) string {
+	http := r.GetAttributes().GetRequest().GetHttp()
+	if value, ok := http.GetHeaders()[name]; ok {
+		return value
+	}
+	for _, header := range http.GetHeaderMap().GetHeaders() {
+		if strings.EqualFold(header.GetKey(), name) {
+			if header.GetValue() != "" {
+				return header.GetValue()
+			}
+			return string(header.GetRawValue())
+		}
+	}
+	return ""
+}
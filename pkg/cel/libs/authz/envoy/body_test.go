@@ -0,0 +1,100 @@
+package envoy_test
+
+import (
+	"testing"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/google/cel-go/cel"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/envoy"
+	"github.com/stretchr/testify/assert"
+)
+
+func httpRequest(http *authv3.AttributeContext_HttpRequest) *authv3.CheckRequest {
+	return &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: http,
+			},
+		},
+	}
+}
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     *authv3.CheckRequest
+		wantBody    string
+		wantPartial bool
+		wantErr     bool
+	}{{
+		name:     "body",
+		request:  httpRequest(&authv3.AttributeContext_HttpRequest{Body: "hello", Size: 5}),
+		wantBody: "hello",
+	}, {
+		name:     "raw body",
+		request:  httpRequest(&authv3.AttributeContext_HttpRequest{RawBody: []byte("hello"), Size: 5}),
+		wantBody: "hello",
+	}, {
+		name:     "no body",
+		request:  httpRequest(&authv3.AttributeContext_HttpRequest{Size: 0}),
+		wantBody: "",
+	}, {
+		name:    "chunked body not sent",
+		request: httpRequest(&authv3.AttributeContext_HttpRequest{Size: -1}),
+		wantErr: true,
+	}, {
+		name: "empty chunked body",
+		request: httpRequest(&authv3.AttributeContext_HttpRequest{
+			Size:    -1,
+			Headers: map[string]string{envoy.PartialBodyHeader: "false"},
+		}),
+		wantBody: "",
+	}, {
+		name:    "body not sent",
+		request: httpRequest(&authv3.AttributeContext_HttpRequest{Size: 5}),
+		wantErr: true,
+	}, {
+		name: "partial header",
+		request: httpRequest(&authv3.AttributeContext_HttpRequest{
+			Body:    "hel",
+			Size:    -1,
+			Headers: map[string]string{envoy.PartialBodyHeader: "true"},
+		}),
+		wantBody:    "hel",
+		wantPartial: true,
+		wantErr:     true,
+	}, {
+		name:        "smaller than size",
+		request:     httpRequest(&authv3.AttributeContext_HttpRequest{Body: "hel", Size: 5}),
+		wantBody:    "hel",
+		wantPartial: true,
+		wantErr:     true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantBody, string(envoy.Body(tt.request)))
+			assert.Equal(t, tt.wantPartial, envoy.IsPartialBody(tt.request))
+			body, err := envoy.RequireBody(tt.request)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantBody, string(body))
+			}
+		})
+	}
+}
+
+func TestRequireBodyFailsEvaluation(t *testing.T) {
+	env, err := cel.NewEnv(envoy.Lib(), cel.Variable("object", envoy.CheckRequest))
+	assert.NoError(t, err)
+	ast, issues := env.Compile(`string(object.RequireBody()).contains("admin")`)
+	assert.Nil(t, issues)
+	prog, err := env.Program(ast)
+	assert.NoError(t, err)
+	_, _, err = prog.Eval(map[string]any{"object": httpRequest(&authv3.AttributeContext_HttpRequest{Body: "hel", Size: 5})})
+	assert.Error(t, err)
+	out, _, err := prog.Eval(map[string]any{"object": httpRequest(&authv3.AttributeContext_HttpRequest{Body: "admin", Size: 5})})
+	assert.NoError(t, err)
+	assert.Equal(t, true, out.Value())
+}
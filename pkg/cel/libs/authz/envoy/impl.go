@@ -236,3 +236,76 @@ func (c *impl) response_with_metadata(response ref.Val, metadata ref.Val) ref.Va
 		return c.NativeToValue(response)
 	}
 }
+
+func (c *impl) response_with_metadata_string_dyn(values ...ref.Val) ref.Val {
+	if response, err := utils.ConvertToNative[*authv3.CheckResponse](values[0]); err != nil {
+		return types.WrapErr(err)
+	} else if key, err := utils.ConvertToNative[string](values[1]); err != nil {
+		return types.WrapErr(err)
+	} else if value, err := utils.ConvertToNative[*structpb.Value](values[2]); err != nil {
+		return types.WrapErr(err)
+	} else {
+		if response.DynamicMetadata == nil {
+			response.DynamicMetadata = &structpb.Struct{}
+		}
+		if response.DynamicMetadata.Fields == nil {
+			response.DynamicMetadata.Fields = map[string]*structpb.Value{}
+		}
+		response.DynamicMetadata.Fields[key] = value
+		return c.NativeToValue(response)
+	}
+}
+
+func (c *impl) request_filter_metadata_string(request ref.Val, name ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*authv3.CheckRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else if name, err := utils.ConvertToNative[string](name); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(filterMetadata(request.GetAttributes().GetMetadataContext(), name))
+	}
+}
+
+func (c *impl) request_route_metadata_string(request ref.Val, name ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*authv3.CheckRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else if name, err := utils.ConvertToNative[string](name); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(filterMetadata(request.GetAttributes().GetRouteMetadataContext(), name))
+	}
+}
+
+// filterMetadata returns the metadata of the filter with the given name, it never returns nil.
+func filterMetadata(metadata *corev3.Metadata, name string) *structpb.Struct {
+	if out := metadata.GetFilterMetadata()[name]; out != nil {
+		return out
+	}
+	return &structpb.Struct{Fields: map[string]*structpb.Value{}}
+}
+
+func (c *impl) request_body(request ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*authv3.CheckRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return types.Bytes(Body(request))
+	}
+}
+
+func (c *impl) request_is_partial_body(request ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*authv3.CheckRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return types.Bool(IsPartialBody(request))
+	}
+}
+
+func (c *impl) request_require_body(request ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*authv3.CheckRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else if body, err := RequireBody(request); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return types.Bytes(body)
+	}
+}
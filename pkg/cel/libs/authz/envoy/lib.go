@@ -105,6 +105,22 @@ func (*lib) extendEnv(env *cel.Env) (*cel.Env, error) {
 		},
 		"WithMetadata": {
 			cel.MemberOverload("response_ok_with_metadata", []*cel.Type{CheckResponse, Metadata}, CheckResponse, cel.BinaryBinding(impl.response_with_metadata)),
+			cel.MemberOverload("response_with_metadata_string_dyn", []*cel.Type{CheckResponse, types.StringType, types.DynType}, CheckResponse, cel.FunctionBinding(impl.response_with_metadata_string_dyn)),
+		},
+		"Body": {
+			cel.MemberOverload("request_body", []*cel.Type{CheckRequest}, types.BytesType, cel.UnaryBinding(impl.request_body)),
+		},
+		"IsPartialBody": {
+			cel.MemberOverload("request_is_partial_body", []*cel.Type{CheckRequest}, types.BoolType, cel.UnaryBinding(impl.request_is_partial_body)),
+		},
+		"RequireBody": {
+			cel.MemberOverload("request_require_body", []*cel.Type{CheckRequest}, types.BytesType, cel.UnaryBinding(impl.request_require_body)),
+		},
+		"FilterMetadata": {
+			cel.MemberOverload("request_filter_metadata_string", []*cel.Type{CheckRequest, types.StringType}, Metadata, cel.BinaryBinding(impl.request_filter_metadata_string)),
+		},
+		"RouteMetadata": {
+			cel.MemberOverload("request_route_metadata_string", []*cel.Type{CheckRequest, types.StringType}, Metadata, cel.BinaryBinding(impl.request_route_metadata_string)),
 		},
 	}
 	// create env options corresponding to our function overloads
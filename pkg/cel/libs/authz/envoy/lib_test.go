@@ -85,6 +85,29 @@ func TestOkResponse(t *testing.T) {
 			}
 		}
 		`,
+	}, {
+		name: "with metadata namespaces",
+		want: &authv3.CheckResponse{
+			Status:       &status.Status{Code: 0},
+			HttpResponse: &authv3.CheckResponse_OkResponse{OkResponse: &authv3.OkHttpResponse{}},
+			DynamicMetadata: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"tenant": structpb.NewStringValue("acme"),
+					"ratelimit": structpb.NewStructValue(&structpb.Struct{
+						Fields: map[string]*structpb.Value{
+							"tier": structpb.NewStringValue("gold"),
+						},
+					}),
+				},
+			},
+		},
+		source: `
+		envoy
+			.Allowed()
+			.Response()
+			.WithMetadata("tenant", "acme")
+			.WithMetadata("ratelimit", {"tier": "gold"})
+		`,
 	}, {
 		name: "with response",
 		want: &authv3.CheckResponse{
@@ -114,3 +137,62 @@ func TestOkResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestMetadata(t *testing.T) {
+	request := &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			MetadataContext: &corev3.Metadata{
+				FilterMetadata: map[string]*structpb.Struct{
+					"envoy.filters.http.jwt_authn": {
+						Fields: map[string]*structpb.Value{
+							"tenant": structpb.NewStringValue("acme"),
+						},
+					},
+				},
+			},
+			RouteMetadataContext: &corev3.Metadata{
+				FilterMetadata: map[string]*structpb.Struct{
+					"kyverno": {
+						Fields: map[string]*structpb.Value{
+							"public": structpb.NewBoolValue(true),
+						},
+					},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name   string
+		source string
+		want   bool
+	}{{
+		name:   "filter metadata",
+		source: `object.FilterMetadata("envoy.filters.http.jwt_authn").tenant == "acme"`,
+		want:   true,
+	}, {
+		name:   "missing filter metadata",
+		source: `!("tenant" in object.FilterMetadata("unknown"))`,
+		want:   true,
+	}, {
+		name:   "route metadata",
+		source: `object.RouteMetadata("kyverno").public`,
+		want:   true,
+	}, {
+		name:   "missing route metadata",
+		source: `object.RouteMetadata("envoy.filters.http.jwt_authn").size() == 0`,
+		want:   true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := cel.NewEnv(envoy.Lib(), cel.Variable("object", envoy.CheckRequest))
+			assert.NoError(t, err)
+			ast, issues := env.Compile(tt.source)
+			assert.Nil(t, issues)
+			prog, err := env.Program(ast)
+			assert.NoError(t, err)
+			out, _, err := prog.Eval(map[string]any{"object": request})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.Value())
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package extproc
+
+import (
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// processing phases
+const (
+	PhaseRequestHeaders   = "request_headers"
+	PhaseRequestBody      = "request_body"
+	PhaseRequestTrailers  = "request_trailers"
+	PhaseResponseHeaders  = "response_headers"
+	PhaseResponseBody     = "response_body"
+	PhaseResponseTrailers = "response_trailers"
+)
+
+// Phase returns the processing phase of a request.
+func Phase(r *extprocv3.ProcessingRequest) string {
+	switch r.GetRequest().(type) {
+	case *extprocv3.ProcessingRequest_RequestHeaders:
+		return PhaseRequestHeaders
+	case *extprocv3.ProcessingRequest_RequestBody:
+		return PhaseRequestBody
+	case *extprocv3.ProcessingRequest_RequestTrailers:
+		return PhaseRequestTrailers
+	case *extprocv3.ProcessingRequest_ResponseHeaders:
+		return PhaseResponseHeaders
+	case *extprocv3.ProcessingRequest_ResponseBody:
+		return PhaseResponseBody
+	case *extprocv3.ProcessingRequest_ResponseTrailers:
+		return PhaseResponseTrailers
+	default:
+		return ""
+	}
+}
+
+// Continue returns the response letting a request continue unmodified in its processing phase.
+func Continue(r *extprocv3.ProcessingRequest) *extprocv3.ProcessingResponse {
+	switch r.GetRequest().(type) {
+	case *extprocv3.ProcessingRequest_RequestHeaders:
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{RequestHeaders: &extprocv3.HeadersResponse{}}}
+	case *extprocv3.ProcessingRequest_RequestBody:
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestBody{RequestBody: &extprocv3.BodyResponse{}}}
+	case *extprocv3.ProcessingRequest_RequestTrailers:
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestTrailers{RequestTrailers: &extprocv3.TrailersResponse{}}}
+	case *extprocv3.ProcessingRequest_ResponseHeaders:
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{ResponseHeaders: &extprocv3.HeadersResponse{}}}
+	case *extprocv3.ProcessingRequest_ResponseBody:
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{ResponseBody: &extprocv3.BodyResponse{}}}
+	case *extprocv3.ProcessingRequest_ResponseTrailers:
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseTrailers{ResponseTrailers: &extprocv3.TrailersResponse{}}}
+	default:
+		return &extprocv3.ProcessingResponse{}
+	}
+}
+
+// Headers returns the headers (or trailers) of a request, keys are lower cased.
+func Headers(r *extprocv3.ProcessingRequest) map[string]string {
+	var headers *corev3.HeaderMap
+	switch r := r.GetRequest().(type) {
+	case *extprocv3.ProcessingRequest_RequestHeaders:
+		headers = r.RequestHeaders.GetHeaders()
+	case *extprocv3.ProcessingRequest_ResponseHeaders:
+		headers = r.ResponseHeaders.GetHeaders()
+	case *extprocv3.ProcessingRequest_RequestTrailers:
+		headers = r.RequestTrailers.GetTrailers()
+	case *extprocv3.ProcessingRequest_ResponseTrailers:
+		headers = r.ResponseTrailers.GetTrailers()
+	}
+	out := map[string]string{}
+	for _, header := range headers.GetHeaders() {
+		value := header.GetValue()
+		// envoy sends values in raw_value
+		if value == "" {
+			value = string(header.GetRawValue())
+		}
+		key := strings.ToLower(header.GetKey())
+		if existing, ok := out[key]; ok {
+			value = existing + "," + value
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// Body returns the body of a request.
+func Body(r *extprocv3.ProcessingRequest) []byte {
+	switch r := r.GetRequest().(type) {
+	case *extprocv3.ProcessingRequest_RequestBody:
+		return r.RequestBody.GetBody()
+	case *extprocv3.ProcessingRequest_ResponseBody:
+		return r.ResponseBody.GetBody()
+	}
+	return nil
+}
+
+// commonResponse returns the common response of a processing response, creating it if needed.
+// It returns nil for immediate and trailers responses.
+func commonResponse(r *extprocv3.ProcessingResponse) *extprocv3.CommonResponse {
+	var holder interface {
+		GetResponse() *extprocv3.CommonResponse
+	}
+	switch r := r.GetResponse().(type) {
+	case *extprocv3.ProcessingResponse_RequestHeaders:
+		if r.RequestHeaders.Response == nil {
+			r.RequestHeaders.Response = &extprocv3.CommonResponse{}
+		}
+		holder = r.RequestHeaders
+	case *extprocv3.ProcessingResponse_ResponseHeaders:
+		if r.ResponseHeaders.Response == nil {
+			r.ResponseHeaders.Response = &extprocv3.CommonResponse{}
+		}
+		holder = r.ResponseHeaders
+	case *extprocv3.ProcessingResponse_RequestBody:
+		if r.RequestBody.Response == nil {
+			r.RequestBody.Response = &extprocv3.CommonResponse{}
+		}
+		holder = r.RequestBody
+	case *extprocv3.ProcessingResponse_ResponseBody:
+		if r.ResponseBody.Response == nil {
+			r.ResponseBody.Response = &extprocv3.CommonResponse{}
+		}
+		holder = r.ResponseBody
+	default:
+		return nil
+	}
+	return holder.GetResponse()
+}
+
+// headerMutation returns the header mutation of a processing response, creating it if needed.
+func headerMutation(r *extprocv3.ProcessingResponse) *extprocv3.HeaderMutation {
+	switch resp := r.GetResponse().(type) {
+	case *extprocv3.ProcessingResponse_ImmediateResponse:
+		if resp.ImmediateResponse.Headers == nil {
+			resp.ImmediateResponse.Headers = &extprocv3.HeaderMutation{}
+		}
+		return resp.ImmediateResponse.Headers
+	case *extprocv3.ProcessingResponse_RequestTrailers:
+		if resp.RequestTrailers.HeaderMutation == nil {
+			resp.RequestTrailers.HeaderMutation = &extprocv3.HeaderMutation{}
+		}
+		return resp.RequestTrailers.HeaderMutation
+	case *extprocv3.ProcessingResponse_ResponseTrailers:
+		if resp.ResponseTrailers.HeaderMutation == nil {
+			resp.ResponseTrailers.HeaderMutation = &extprocv3.HeaderMutation{}
+		}
+		return resp.ResponseTrailers.HeaderMutation
+	}
+	common := commonResponse(r)
+	if common == nil {
+		return nil
+	}
+	if common.HeaderMutation == nil {
+		common.HeaderMutation = &extprocv3.HeaderMutation{}
+	}
+	return common.HeaderMutation
+}
+
+// SetBody replaces the body of a processing response.
+func SetBody(r *extprocv3.ProcessingResponse, body []byte) bool {
+	switch resp := r.GetResponse().(type) {
+	case *extprocv3.ProcessingResponse_ImmediateResponse:
+		resp.ImmediateResponse.Body = body
+		return true
+	case *extprocv3.ProcessingResponse_RequestHeaders, *extprocv3.ProcessingResponse_ResponseHeaders:
+		common := commonResponse(r)
+		// replacing the body from a headers response requires CONTINUE_AND_REPLACE
+		common.Status = extprocv3.CommonResponse_CONTINUE_AND_REPLACE
+		common.BodyMutation = &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: body}}
+		return true
+	}
+	common := commonResponse(r)
+	if common == nil {
+		return false
+	}
+	common.BodyMutation = &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: body}}
+	return true
+}
+
+type impl struct {
+	types.Adapter
+}
+
+func (c *impl) continue_request(request ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*extprocv3.ProcessingRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(Continue(request))
+	}
+}
+
+func (c *impl) immediate_int(code ref.Val) ref.Val {
+	if code, err := utils.ConvertToNative[int](code); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(&extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extprocv3.ImmediateResponse{
+					Status: &typev3.HttpStatus{Code: typev3.StatusCode(code)},
+				},
+			},
+		})
+	}
+}
+
+func (c *impl) request_phase(request ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*extprocv3.ProcessingRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return types.String(Phase(request))
+	}
+}
+
+func (c *impl) request_headers(request ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*extprocv3.ProcessingRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(Headers(request))
+	}
+}
+
+func (c *impl) stream_request_headers(stream ref.Val) ref.Val {
+	if stream, err := utils.ConvertToNative[Stream](stream); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(stream.State.RequestHeaders())
+	}
+}
+
+func (c *impl) stream_get_string(stream ref.Val, key ref.Val) ref.Val {
+	if stream, err := utils.ConvertToNative[Stream](stream); err != nil {
+		return types.WrapErr(err)
+	} else if key, err := utils.ConvertToNative[string](key); err != nil {
+		return types.WrapErr(err)
+	} else if value, ok := stream.State.Get(key); ok {
+		return types.OptionalOf(value)
+	} else {
+		return types.OptionalNone
+	}
+}
+
+func (c *impl) stream_set_string_dyn(values ...ref.Val) ref.Val {
+	if stream, err := utils.ConvertToNative[Stream](values[0]); err != nil {
+		return types.WrapErr(err)
+	} else if key, err := utils.ConvertToNative[string](values[1]); err != nil {
+		return types.WrapErr(err)
+	} else {
+		stream.State.Set(key, values[2])
+		return values[2]
+	}
+}
+
+func (c *impl) request_body(request ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*extprocv3.ProcessingRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return types.Bytes(Body(request))
+	}
+}
+
+func (c *impl) response_with_header_string_string(values ...ref.Val) ref.Val {
+	if response, err := utils.ConvertToNative[*extprocv3.ProcessingResponse](values[0]); err != nil {
+		return types.WrapErr(err)
+	} else if key, err := utils.ConvertToNative[string](values[1]); err != nil {
+		return types.WrapErr(err)
+	} else if value, err := utils.ConvertToNative[string](values[2]); err != nil {
+		return types.WrapErr(err)
+	} else if mutation := headerMutation(response); mutation == nil {
+		return types.NewErr("headers can't be set on this response")
+	} else {
+		mutation.SetHeaders = append(mutation.SetHeaders, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: key, RawValue: []byte(value)}})
+		return c.NativeToValue(response)
+	}
+}
+
+func (c *impl) response_without_header_string(response ref.Val, header ref.Val) ref.Val {
+	if response, err := utils.ConvertToNative[*extprocv3.ProcessingResponse](response); err != nil {
+		return types.WrapErr(err)
+	} else if header, err := utils.ConvertToNative[string](header); err != nil {
+		return types.WrapErr(err)
+	} else if mutation := headerMutation(response); mutation == nil {
+		return types.NewErr("headers can't be removed from this response")
+	} else {
+		mutation.RemoveHeaders = append(mutation.RemoveHeaders, header)
+		return c.NativeToValue(response)
+	}
+}
+
+func (c *impl) response_with_body_string(response ref.Val, body ref.Val) ref.Val {
+	if body, err := utils.ConvertToNative[string](body); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.response_with_body(response, []byte(body))
+	}
+}
+
+func (c *impl) response_with_body_bytes(response ref.Val, body ref.Val) ref.Val {
+	if body, err := utils.ConvertToNative[[]byte](body); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.response_with_body(response, body)
+	}
+}
+
+func (c *impl) response_with_body(response ref.Val, body []byte) ref.Val {
+	if response, err := utils.ConvertToNative[*extprocv3.ProcessingResponse](response); err != nil {
+		return types.WrapErr(err)
+	} else if !SetBody(response, body) {
+		return types.NewErr("body can't be set on this response")
+	} else {
+		return c.NativeToValue(response)
+	}
+}
+
+func (c *impl) response_with_metadata(response ref.Val, metadata ref.Val) ref.Val {
+	if response, err := utils.ConvertToNative[*extprocv3.ProcessingResponse](response); err != nil {
+		return types.WrapErr(err)
+	} else if metadata, err := utils.ConvertToNative[*structpb.Struct](metadata); err != nil {
+		return types.WrapErr(err)
+	} else {
+		response.DynamicMetadata = metadata
+		return c.NativeToValue(response)
+	}
+}
+
+func (c *impl) json_remove_bytes_list(body ref.Val, paths ref.Val) ref.Val {
+	if body, err := utils.ConvertToNative[[]byte](body); err != nil {
+		return types.WrapErr(err)
+	} else if paths, err := utils.ConvertToNative[[]string](paths); err != nil {
+		return types.WrapErr(err)
+	} else if out, err := JsonRemove(body, paths...); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return types.Bytes(out)
+	}
+}
+
+func (c *impl) json_mask_bytes_list(body ref.Val, paths ref.Val) ref.Val {
+	return c.json_mask_bytes_list_string(body, paths, types.String(DefaultMask))
+}
+
+func (c *impl) json_mask_bytes_list_string(values ...ref.Val) ref.Val {
+	if body, err := utils.ConvertToNative[[]byte](values[0]); err != nil {
+		return types.WrapErr(err)
+	} else if paths, err := utils.ConvertToNative[[]string](values[1]); err != nil {
+		return types.WrapErr(err)
+	} else if mask, err := utils.ConvertToNative[string](values[2]); err != nil {
+		return types.WrapErr(err)
+	} else if out, err := JsonMask(body, mask, paths...); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return types.Bytes(out)
+	}
+}
+
+func (c *impl) response_with_metadata_string_dyn(values ...ref.Val) ref.Val {
+	if response, err := utils.ConvertToNative[*extprocv3.ProcessingResponse](values[0]); err != nil {
+		return types.WrapErr(err)
+	} else if namespace, err := utils.ConvertToNative[string](values[1]); err != nil {
+		return types.WrapErr(err)
+	} else if value, err := utils.ConvertToNative[*structpb.Value](values[2]); err != nil {
+		return types.WrapErr(err)
+	} else {
+		if response.DynamicMetadata == nil {
+			response.DynamicMetadata = &structpb.Struct{}
+		}
+		if response.DynamicMetadata.Fields == nil {
+			response.DynamicMetadata.Fields = map[string]*structpb.Value{}
+		}
+		response.DynamicMetadata.Fields[namespace] = value
+		return c.NativeToValue(response)
+	}
+}
+
+func (c *impl) request_filter_metadata_string(request ref.Val, name ref.Val) ref.Val {
+	if request, err := utils.ConvertToNative[*extprocv3.ProcessingRequest](request); err != nil {
+		return types.WrapErr(err)
+	} else if name, err := utils.ConvertToNative[string](name); err != nil {
+		return types.WrapErr(err)
+	} else if metadata := request.GetMetadataContext().GetFilterMetadata()[name]; metadata != nil {
+		return c.NativeToValue(metadata)
+	} else {
+		return c.NativeToValue(&structpb.Struct{Fields: map[string]*structpb.Value{}})
+	}
+}
@@ -0,0 +1,234 @@
+package extproc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DefaultMask is the value used to mask JSON fields when no mask is given
+const DefaultMask = "****"
+
+// JsonRemove removes the fields and array elements matching paths from a JSON document.
+func JsonRemove(body []byte, paths ...string) ([]byte, error) {
+	return jsonEdit(body, paths, func(parent any, key string) {
+		switch parent := parent.(type) {
+		case *jsonObject:
+			delete(parent.values, key)
+		case *jsonArray:
+			// elements are dropped when encoding, indexes of the other paths still refer to the original array
+			if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(parent.items) {
+				parent.items[i] = jsonRemoved{}
+			}
+		}
+	})
+}
+
+// JsonMask replaces the values of the fields and array elements matching paths in a JSON document with mask.
+func JsonMask(body []byte, mask string, paths ...string) ([]byte, error) {
+	return jsonEdit(body, paths, func(parent any, key string) {
+		switch parent := parent.(type) {
+		case *jsonObject:
+			if _, ok := parent.values[key]; ok {
+				parent.values[key] = mask
+			}
+		case *jsonArray:
+			if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(parent.items) && parent.items[i] != (jsonRemoved{}) {
+				parent.items[i] = mask
+			}
+		}
+	})
+}
+
+// jsonObject is a decoded JSON object, it preserves the order of the keys.
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// jsonArray is a decoded JSON array, it is a pointer so that elements can be removed.
+type jsonArray struct {
+	items []any
+}
+
+// jsonRemoved marks a removed array element.
+type jsonRemoved struct{}
+
+// jsonEdit decodes body, calls edit for every field matching paths and encodes the result.
+// Path segments are separated by dots, a numeric segment indexes an array and `*` matches
+// every field of an object or every element of an array.
+// Keys keep their order, numbers and strings are encoded as they were decoded (HTML characters are not escaped).
+func jsonEdit(body []byte, paths []string, edit func(parent any, key string)) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// preserve numbers as they are
+	decoder.UseNumber()
+	doc, err := jsonDecode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	// the body must hold a single JSON value
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after the JSON value")
+		}
+		return nil, err
+	}
+	for _, path := range paths {
+		jsonWalk(doc, strings.Split(path, "."), edit)
+	}
+	var out bytes.Buffer
+	if err := jsonEncode(&out, doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func jsonDecode(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := &jsonObject{values: map[string]any{}}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := jsonDecode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			name := key.(string)
+			if _, ok := object.values[name]; !ok {
+				object.keys = append(object.keys, name)
+			}
+			object.values[name] = value
+		}
+		// consume the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return object, nil
+	case json.Delim('['):
+		array := &jsonArray{}
+		for decoder.More() {
+			value, err := jsonDecode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array.items = append(array.items, value)
+		}
+		// consume the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return array, nil
+	case json.Delim('}'), json.Delim(']'):
+		return nil, fmt.Errorf("unexpected delimiter %v", token)
+	default:
+		// strings, numbers, booleans and null
+		return token, nil
+	}
+}
+
+func jsonEncode(out *bytes.Buffer, node any) error {
+	switch node := node.(type) {
+	case *jsonObject:
+		out.WriteByte('{')
+		first := true
+		for _, key := range node.keys {
+			value, ok := node.values[key]
+			if !ok {
+				continue
+			}
+			if !first {
+				out.WriteByte(',')
+			}
+			first = false
+			if err := jsonEncode(out, key); err != nil {
+				return err
+			}
+			out.WriteByte(':')
+			if err := jsonEncode(out, value); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case *jsonArray:
+		out.WriteByte('[')
+		first := true
+		for _, item := range node.items {
+			if item == (jsonRemoved{}) {
+				continue
+			}
+			if !first {
+				out.WriteByte(',')
+			}
+			first = false
+			if err := jsonEncode(out, item); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	default:
+		encoder := json.NewEncoder(out)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(node); err != nil {
+			return err
+		}
+		// drop the newline added by the encoder
+		out.Truncate(out.Len() - 1)
+	}
+	return nil
+}
+
+func jsonWalk(node any, segments []string, edit func(parent any, key string)) {
+	if len(segments) == 0 {
+		return
+	}
+	segment, rest := segments[0], segments[1:]
+	switch node := node.(type) {
+	case *jsonObject:
+		keys := []string{segment}
+		if segment == "*" {
+			keys = keys[:0]
+			for _, key := range node.keys {
+				if _, ok := node.values[key]; ok {
+					keys = append(keys, key)
+				}
+			}
+		}
+		for _, key := range keys {
+			if len(rest) == 0 {
+				edit(node, key)
+			} else if child, ok := node.values[key]; ok {
+				jsonWalk(child, rest, edit)
+			}
+		}
+	case *jsonArray:
+		if segment == "*" {
+			for i, child := range node.items {
+				if child == (jsonRemoved{}) {
+					continue
+				}
+				if len(rest) == 0 {
+					edit(node, strconv.Itoa(i))
+				} else {
+					jsonWalk(child, rest, edit)
+				}
+			}
+		} else if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(node.items) {
+			if len(rest) == 0 {
+				edit(node, segment)
+			} else {
+				jsonWalk(node.items[i], rest, edit)
+			}
+		}
+	}
+}
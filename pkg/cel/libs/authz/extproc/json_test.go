@@ -0,0 +1,130 @@
+package extproc_test
+
+import (
+	"testing"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonRemove(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		paths   []string
+		want    string
+		wantErr bool
+	}{{
+		name:  "field",
+		body:  `{"name":"john","ssn":"123"}`,
+		paths: []string{"ssn"},
+		want:  `{"name":"john"}`,
+	}, {
+		name:  "nested",
+		body:  `{"user":{"name":"john","ssn":"123"},"id":12345678901234567890}`,
+		paths: []string{"user.ssn"},
+		want:  `{"user":{"name":"john"},"id":12345678901234567890}`,
+	}, {
+		name:  "wildcard",
+		body:  `{"items":[{"name":"a","ssn":"1"},{"name":"b","ssn":"2"}]}`,
+		paths: []string{"items.*.ssn"},
+		want:  `{"items":[{"name":"a"},{"name":"b"}]}`,
+	}, {
+		name:  "index",
+		body:  `[{"name":"a","ssn":"1"},{"name":"b","ssn":"2"}]`,
+		paths: []string{"1.ssn"},
+		want:  `[{"name":"a","ssn":"1"},{"name":"b"}]`,
+	}, {
+		name:  "missing",
+		body:  `{"name":"john"}`,
+		paths: []string{"user.ssn", "ssn"},
+		want:  `{"name":"john"}`,
+	}, {
+		name:  "array elements",
+		body:  `{"tokens":["a","b","c"]}`,
+		paths: []string{"tokens.0", "tokens.2"},
+		want:  `{"tokens":["b"]}`,
+	}, {
+		name:  "all array elements",
+		body:  `{"tokens":["a","b"],"id":1}`,
+		paths: []string{"tokens.*"},
+		want:  `{"tokens":[],"id":1}`,
+	}, {
+		name:  "order and html",
+		body:  `{"z":"<b>&</b>","a":{"y":1.50,"b":null},"m":[true,false]}`,
+		paths: []string{"a.b"},
+		want:  `{"z":"<b>&</b>","a":{"y":1.50},"m":[true,false]}`,
+	}, {
+		name:    "invalid",
+		body:    `{"name":`,
+		paths:   []string{"name"},
+		wantErr: true,
+	}, {
+		name:    "trailing data",
+		body:    `{"name":"foo"} garbage`,
+		paths:   []string{"name"},
+		wantErr: true,
+	}, {
+		name:    "multiple values",
+		body:    `{"name":"foo"}{"name":"bar"}`,
+		paths:   []string{"name"},
+		wantErr: true,
+	}, {
+		name:  "trailing whitespace",
+		body:  "{\"name\":\"foo\",\"id\":1}\n",
+		paths: []string{"name"},
+		want:  `{"id":1}`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extproc.JsonRemove([]byte(tt.body), tt.paths...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, string(got))
+			}
+		})
+	}
+}
+
+func TestJsonMask(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		paths []string
+		want  string
+	}{{
+		name:  "field",
+		body:  `{"name":"john","ssn":"123"}`,
+		paths: []string{"ssn"},
+		want:  `{"name":"john","ssn":"****"}`,
+	}, {
+		name:  "wildcard",
+		body:  `{"items":[{"card":{"number":1234}},{"card":{"number":5678}}]}`,
+		paths: []string{"items.*.card.number"},
+		want:  `{"items":[{"card":{"number":"****"}},{"card":{"number":"****"}}]}`,
+	}, {
+		name:  "array elements",
+		body:  `{"tokens":["a","b"]}`,
+		paths: []string{"tokens.*"},
+		want:  `{"tokens":["****","****"]}`,
+	}, {
+		name:  "index",
+		body:  `{"cards":["1234","5678"]}`,
+		paths: []string{"cards.1"},
+		want:  `{"cards":["1234","****"]}`,
+	}, {
+		name:  "missing",
+		body:  `{"name":"john"}`,
+		paths: []string{"ssn"},
+		want:  `{"name":"john"}`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extproc.JsonMask([]byte(tt.body), extproc.DefaultMask, tt.paths...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
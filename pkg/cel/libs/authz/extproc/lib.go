@@ -0,0 +1,120 @@
+package extproc
+
+import (
+	"reflect"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var (
+	// envoy ext_proc types
+	ProcessingRequest  = types.NewObjectType("envoy.service.ext_proc.v3.ProcessingRequest")
+	ProcessingResponse = types.NewObjectType("envoy.service.ext_proc.v3.ProcessingResponse")
+	Metadata           = types.NewObjectType("google.protobuf.Struct")
+)
+
+type lib struct{}
+
+func Lib() cel.EnvOption {
+	// create the cel lib env option
+	return cel.Lib(&lib{})
+}
+
+func (*lib) LibraryName() string {
+	return "kyverno.authz.extproc"
+}
+
+func (c *lib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		// register envoy protobuf messages
+		cel.Types(
+			(*extprocv3.ProcessingRequest)(nil),
+			(*extprocv3.ProcessingResponse)(nil),
+			(*structpb.Struct)(nil),
+		),
+		cel.Variable("stream", StreamType),
+		// register native types
+		ext.NativeTypes(reflect.TypeFor[Stream]()),
+		// extend environment with function overloads
+		c.extendEnv,
+	}
+}
+
+func (*lib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{
+		cel.Globals(
+			map[string]any{
+				"stream": Stream{},
+			},
+		),
+	}
+}
+
+func (*lib) extendEnv(env *cel.Env) (*cel.Env, error) {
+	// create implementation, recording the envoy types aware adapter
+	impl := impl{
+		Adapter: env.CELTypeAdapter(),
+	}
+	// build our function overloads
+	libraryDecls := map[string][]cel.FunctionOpt{
+		"extproc.Continue": {
+			cel.Overload("continue_request", []*cel.Type{ProcessingRequest}, ProcessingResponse, cel.UnaryBinding(impl.continue_request)),
+		},
+		"extproc.Immediate": {
+			cel.Overload("immediate_int", []*cel.Type{types.IntType}, ProcessingResponse, cel.UnaryBinding(impl.immediate_int)),
+		},
+		"extproc.JsonRemove": {
+			cel.Overload("json_remove_bytes_list", []*cel.Type{types.BytesType, types.NewListType(types.StringType)}, types.BytesType, cel.BinaryBinding(impl.json_remove_bytes_list)),
+		},
+		"extproc.JsonMask": {
+			cel.Overload("json_mask_bytes_list", []*cel.Type{types.BytesType, types.NewListType(types.StringType)}, types.BytesType, cel.BinaryBinding(impl.json_mask_bytes_list)),
+			cel.Overload("json_mask_bytes_list_string", []*cel.Type{types.BytesType, types.NewListType(types.StringType), types.StringType}, types.BytesType, cel.FunctionBinding(impl.json_mask_bytes_list_string)),
+		},
+		"Phase": {
+			cel.MemberOverload("request_phase", []*cel.Type{ProcessingRequest}, types.StringType, cel.UnaryBinding(impl.request_phase)),
+		},
+		"Headers": {
+			cel.MemberOverload("request_headers", []*cel.Type{ProcessingRequest}, types.NewMapType(types.StringType, types.StringType), cel.UnaryBinding(impl.request_headers)),
+		},
+		"RequestHeaders": {
+			cel.MemberOverload("stream_request_headers", []*cel.Type{StreamType}, types.NewMapType(types.StringType, types.StringType), cel.UnaryBinding(impl.stream_request_headers)),
+		},
+		"Get": {
+			cel.MemberOverload("stream_get_string", []*cel.Type{StreamType, types.StringType}, types.NewOptionalType(types.DynType), cel.BinaryBinding(impl.stream_get_string)),
+		},
+		"Set": {
+			cel.MemberOverload("stream_set_string_dyn", []*cel.Type{StreamType, types.StringType, types.DynType}, types.DynType, cel.FunctionBinding(impl.stream_set_string_dyn)),
+		},
+		"Body": {
+			cel.MemberOverload("request_body", []*cel.Type{ProcessingRequest}, types.BytesType, cel.UnaryBinding(impl.request_body)),
+		},
+		"WithHeader": {
+			cel.MemberOverload("response_with_header_string_string", []*cel.Type{ProcessingResponse, types.StringType, types.StringType}, ProcessingResponse, cel.FunctionBinding(impl.response_with_header_string_string)),
+		},
+		"WithoutHeader": {
+			cel.MemberOverload("response_without_header_string", []*cel.Type{ProcessingResponse, types.StringType}, ProcessingResponse, cel.BinaryBinding(impl.response_without_header_string)),
+		},
+		"WithBody": {
+			cel.MemberOverload("response_with_body_string", []*cel.Type{ProcessingResponse, types.StringType}, ProcessingResponse, cel.BinaryBinding(impl.response_with_body_string)),
+			cel.MemberOverload("response_with_body_bytes", []*cel.Type{ProcessingResponse, types.BytesType}, ProcessingResponse, cel.BinaryBinding(impl.response_with_body_bytes)),
+		},
+		"WithMetadata": {
+			cel.MemberOverload("response_with_metadata", []*cel.Type{ProcessingResponse, Metadata}, ProcessingResponse, cel.BinaryBinding(impl.response_with_metadata)),
+			cel.MemberOverload("response_with_metadata_string_dyn", []*cel.Type{ProcessingResponse, types.StringType, types.DynType}, ProcessingResponse, cel.FunctionBinding(impl.response_with_metadata_string_dyn)),
+		},
+		"FilterMetadata": {
+			cel.MemberOverload("request_filter_metadata_string", []*cel.Type{ProcessingRequest, types.StringType}, Metadata, cel.BinaryBinding(impl.request_filter_metadata_string)),
+		},
+	}
+	// create env options corresponding to our function overloads
+	options := []cel.EnvOption{}
+	for name, overloads := range libraryDecls {
+		options = append(options, cel.Function(name, overloads...))
+	}
+	// extend environment with our function overloads
+	return env.Extend(options...)
+}
@@ -0,0 +1,247 @@
+package extproc_test
+
+import (
+	"reflect"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/google/cel-go/cel"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestResponse(t *testing.T) {
+	requestHeaders := &extprocv3.ProcessingRequest{
+		Request: &extprocv3.ProcessingRequest_RequestHeaders{
+			RequestHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{
+					Headers: []*corev3.HeaderValue{
+						{Key: "X-Foo", RawValue: []byte("bar")},
+						{Key: ":path", Value: "/"},
+					},
+				},
+			},
+		},
+	}
+	responseBody := &extprocv3.ProcessingRequest{
+		Request: &extprocv3.ProcessingRequest_ResponseBody{
+			ResponseBody: &extprocv3.HttpBody{
+				Body:        []byte(`{"ssn":"123"}`),
+				EndOfStream: true,
+			},
+		},
+	}
+	state := extproc.NewState()
+	state.Record(&extprocv3.ProcessingRequest{
+		Request: &extprocv3.ProcessingRequest_RequestHeaders{
+			RequestHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{
+					Headers: []*corev3.HeaderValue{{Key: "X-Role", RawValue: []byte("viewer")}},
+				},
+			},
+		},
+	})
+	tests := []struct {
+		name    string
+		request *extprocv3.ProcessingRequest
+		source  string
+		want    *extprocv3.ProcessingResponse
+	}{{
+		name:    "continue",
+		request: requestHeaders,
+		source:  `extproc.Continue(object)`,
+		want: &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_RequestHeaders{
+				RequestHeaders: &extprocv3.HeadersResponse{},
+			},
+		},
+	}, {
+		name:    "headers",
+		request: requestHeaders,
+		source: `
+		object.Phase() == "request_headers" && object.Headers()["x-foo"] == "bar"
+			? extproc.Continue(object).WithHeader("x-bar", object.Headers()[":path"]).WithoutHeader("x-foo")
+			: extproc.Immediate(403)
+		`,
+		want: &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_RequestHeaders{
+				RequestHeaders: &extprocv3.HeadersResponse{
+					Response: &extprocv3.CommonResponse{
+						HeaderMutation: &extprocv3.HeaderMutation{
+							SetHeaders: []*corev3.HeaderValueOption{{
+								Header: &corev3.HeaderValue{Key: "x-bar", RawValue: []byte("/")},
+							}},
+							RemoveHeaders: []string{"x-foo"},
+						},
+					},
+				},
+			},
+		},
+	}, {
+		name:    "body",
+		request: responseBody,
+		source: `
+		object.Phase() == "response_body" && string(object.Body()).contains("ssn")
+			? extproc.Continue(object).WithBody("{}").WithMetadata({"redacted": true})
+			: extproc.Continue(object)
+		`,
+		want: &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ResponseBody{
+				ResponseBody: &extprocv3.BodyResponse{
+					Response: &extprocv3.CommonResponse{
+						BodyMutation: &extprocv3.BodyMutation{
+							Mutation: &extprocv3.BodyMutation_Body{Body: []byte("{}")},
+						},
+					},
+				},
+			},
+			DynamicMetadata: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"redacted": structpb.NewBoolValue(true),
+				},
+			},
+		},
+	}, {
+		name: "metadata per key",
+		request: &extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_ResponseBody{
+				ResponseBody: &extprocv3.HttpBody{EndOfStream: true},
+			},
+			MetadataContext: &corev3.Metadata{
+				FilterMetadata: map[string]*structpb.Struct{
+					"envoy.filters.http.jwt_authn": {
+						Fields: map[string]*structpb.Value{
+							"tenant": structpb.NewStringValue("acme"),
+						},
+					},
+				},
+			},
+		},
+		source: `
+		extproc.Continue(object).WithMetadata("envoy.filters.http.ext_proc", {"tenant": object.FilterMetadata("envoy.filters.http.jwt_authn").tenant})
+		`,
+		want: &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ResponseBody{
+				ResponseBody: &extprocv3.BodyResponse{},
+			},
+			DynamicMetadata: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"envoy.filters.http.ext_proc": structpb.NewStructValue(&structpb.Struct{
+						Fields: map[string]*structpb.Value{
+							"tenant": structpb.NewStringValue("acme"),
+						},
+					}),
+				},
+			},
+		},
+	}, {
+		name: "redaction",
+		request: &extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_ResponseBody{
+				ResponseBody: &extprocv3.HttpBody{
+					Body:        []byte(`{"name":"john","ssn":"123","card":"4242"}`),
+					EndOfStream: true,
+				},
+			},
+		},
+		source: `
+		stream.RequestHeaders()["x-role"] == "admin"
+			? extproc.Continue(object)
+			: extproc.Continue(object).WithBody(extproc.JsonMask(extproc.JsonRemove(object.Body(), ["ssn"]), ["card"], "XXXX"))
+		`,
+		want: &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ResponseBody{
+				ResponseBody: &extprocv3.BodyResponse{
+					Response: &extprocv3.CommonResponse{
+						BodyMutation: &extprocv3.BodyMutation{
+							Mutation: &extprocv3.BodyMutation_Body{Body: []byte(`{"name":"john","card":"XXXX"}`)},
+						},
+					},
+				},
+			},
+		},
+	}, {
+		name:    "stream values",
+		request: responseBody,
+		source: `
+		stream.Set("role", "viewer") == "viewer" && stream.Get("role").orValue("") == "viewer" && !stream.Get("missing").hasValue()
+			? extproc.Immediate(403)
+			: extproc.Continue(object)
+		`,
+		want: &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extprocv3.ImmediateResponse{
+					Status: &typev3.HttpStatus{Code: 403},
+				},
+			},
+		},
+	}, {
+		name:    "immediate",
+		request: responseBody,
+		source:  `extproc.Immediate(451).WithHeader("x-reason", "legal").WithBody(b"nope")`,
+		want: &extprocv3.ProcessingResponse{
+			Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extprocv3.ImmediateResponse{
+					Status: &typev3.HttpStatus{Code: 451},
+					Headers: &extprocv3.HeaderMutation{
+						SetHeaders: []*corev3.HeaderValueOption{{
+							Header: &corev3.HeaderValue{Key: "x-reason", RawValue: []byte("legal")},
+						}},
+					},
+					Body: []byte("nope"),
+				},
+			},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := cel.NewEnv(cel.OptionalTypes(), extproc.Lib(), cel.Variable("object", extproc.ProcessingRequest))
+			assert.NoError(t, err)
+			ast, issues := env.Compile(tt.source)
+			assert.Nil(t, issues)
+			prog, err := env.Program(ast)
+			assert.NoError(t, err)
+			assert.NotNil(t, prog)
+			out, _, err := prog.Eval(map[string]any{"object": tt.request, "stream": extproc.Stream{State: state}})
+			assert.NoError(t, err)
+			assert.NotNil(t, out)
+			got, err := out.ConvertToNative(reflect.TypeFor[*extprocv3.ProcessingResponse]())
+			assert.NoError(t, err)
+			assert.EqualExportedValues(t, tt.want, got)
+		})
+	}
+}
+
+func TestContinue(t *testing.T) {
+	tests := []struct {
+		request *extprocv3.ProcessingRequest
+		phase   string
+	}{{
+		request: &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestHeaders{}},
+		phase:   extproc.PhaseRequestHeaders,
+	}, {
+		request: &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestBody{}},
+		phase:   extproc.PhaseRequestBody,
+	}, {
+		request: &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestTrailers{}},
+		phase:   extproc.PhaseRequestTrailers,
+	}, {
+		request: &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseHeaders{}},
+		phase:   extproc.PhaseResponseHeaders,
+	}, {
+		request: &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseBody{}},
+		phase:   extproc.PhaseResponseBody,
+	}, {
+		request: &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseTrailers{}},
+		phase:   extproc.PhaseResponseTrailers,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.phase, func(t *testing.T) {
+			assert.Equal(t, tt.phase, extproc.Phase(tt.request))
+			assert.NotNil(t, extproc.Continue(tt.request).GetResponse())
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package extproc
+
+import (
+	"context"
+	"sync"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// StreamType is the type of the stream variable giving policies access to the state of a processing stream.
+var StreamType = types.NewOpaqueType("extproc.Stream")
+
+// Stream is the receiver of the stream functions.
+type Stream struct {
+	State *State
+}
+
+// State holds the state of a processing stream, it is shared by the requests received for the phases of the stream.
+// The server records the request headers, policies can store values computed in a phase (a decoded identity for
+// example) and read them in the following phases.
+// A nil State holds no state and doesn't record anything.
+type State struct {
+	lock           sync.Mutex
+	requestHeaders map[string]string
+	values         map[string]ref.Val
+}
+
+func NewState() *State {
+	return &State{
+		values: map[string]ref.Val{},
+	}
+}
+
+// Record records the state carried by a request, the request headers are recorded in the request_headers phase.
+func (s *State) Record(r *extprocv3.ProcessingRequest) {
+	if s == nil || Phase(r) != PhaseRequestHeaders {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requestHeaders = Headers(r)
+}
+
+// RequestHeaders returns the request headers of the stream, keys are lower cased.
+func (s *State) RequestHeaders() map[string]string {
+	out := map[string]string{}
+	if s == nil {
+		return out
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key, value := range s.requestHeaders {
+		out[key] = value
+	}
+	return out
+}
+
+// Get returns the value stored under key, if any.
+func (s *State) Get(key string) (ref.Val, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set stores a value under key, it is available to the policies evaluated for the following phases of the stream.
+func (s *State) Set(key string, value ref.Val) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values[key] = value
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the given State, policies evaluated with this context share it.
+func NewContext(ctx context.Context, s *State) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the State carried by the context, if any.
+func FromContext(ctx context.Context) *State {
+	if s, ok := ctx.Value(contextKey{}).(*State); ok {
+		return s
+	}
+	return nil
+}
@@ -1,11 +1,17 @@
 package http
 
 import (
+	"encoding/json"
+	"net/http"
 	"net/textproto"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type impl struct {
@@ -69,3 +75,242 @@ func (c *impl) response_denied(response ref.Val) ref.Val {
 		return c.NativeToValue(r)
 	}
 }
+
+// addHeader returns a copy of h with value appended to the values of key.
+func addHeader(h header, key, value string) header {
+	out := make(header, len(h)+1)
+	for k, v := range h {
+		out[k] = slices.Clone(v)
+	}
+	key = textproto.CanonicalMIMEHeaderKey(key)
+	out[key] = append(out[key], value)
+	return out
+}
+
+func (c *impl) ok_with_header(values ...ref.Val) ref.Val {
+	if ok, err := utils.ConvertToNative[CheckResponseOk](values[0]); err != nil {
+		return types.WrapErr(err)
+	} else if key, err := utils.ConvertToNative[string](values[1]); err != nil {
+		return types.WrapErr(err)
+	} else if value, err := utils.ConvertToNative[string](values[2]); err != nil {
+		return types.WrapErr(err)
+	} else {
+		ok.Header = addHeader(ok.Header, key, value)
+		return c.NativeToValue(ok)
+	}
+}
+
+func (c *impl) ok_with_response_header(values ...ref.Val) ref.Val {
+	if ok, err := utils.ConvertToNative[CheckResponseOk](values[0]); err != nil {
+		return types.WrapErr(err)
+	} else if key, err := utils.ConvertToNative[string](values[1]); err != nil {
+		return types.WrapErr(err)
+	} else if value, err := utils.ConvertToNative[string](values[2]); err != nil {
+		return types.WrapErr(err)
+	} else {
+		ok.ResponseHeader = addHeader(ok.ResponseHeader, key, value)
+		return c.NativeToValue(ok)
+	}
+}
+
+func (c *impl) denied_with_header(values ...ref.Val) ref.Val {
+	if denied, err := utils.ConvertToNative[CheckResponseDenied](values[0]); err != nil {
+		return types.WrapErr(err)
+	} else if key, err := utils.ConvertToNative[string](values[1]); err != nil {
+		return types.WrapErr(err)
+	} else if value, err := utils.ConvertToNative[string](values[2]); err != nil {
+		return types.WrapErr(err)
+	} else {
+		denied.Header = addHeader(denied.Header, key, value)
+		return c.NativeToValue(denied)
+	}
+}
+
+func (c *impl) denied_with_body(denied ref.Val, body ref.Val) ref.Val {
+	if denied, err := utils.ConvertToNative[CheckResponseDenied](denied); err != nil {
+		return types.WrapErr(err)
+	} else if body, err := utils.ConvertToNative[string](body); err != nil {
+		return types.WrapErr(err)
+	} else {
+		denied.Body = body
+		return c.NativeToValue(denied)
+	}
+}
+
+func (c *impl) denied_with_status(denied ref.Val, status ref.Val) ref.Val {
+	if denied, err := utils.ConvertToNative[CheckResponseDenied](denied); err != nil {
+		return types.WrapErr(err)
+	} else if status, err := utils.ConvertToNative[int](status); err != nil {
+		return types.WrapErr(err)
+	} else if status < 100 || status > 599 {
+		return types.NewErr("invalid http status code: %d", status)
+	} else if denied, err := setStatus(denied, status); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(denied)
+	}
+}
+
+// setStatus returns a copy of denied with the given status, problem details set with WithProblemDetails
+// are updated so that their status (and default title) keep matching the response status.
+func setStatus(denied CheckResponseDenied, status int) (CheckResponseDenied, error) {
+	previous := denied.Status
+	denied.Status = status
+	if textproto.MIMEHeader(denied.Header).Get("Content-Type") != ProblemContentType {
+		return denied, nil
+	}
+	var details map[string]any
+	if err := json.Unmarshal([]byte(denied.Body), &details); err != nil || details == nil {
+		// the body was replaced afterwards, leave it untouched
+		return denied, nil
+	}
+	details["status"] = status
+	if details["title"] == http.StatusText(previous) {
+		details["title"] = http.StatusText(status)
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return denied, err
+	}
+	denied.Body = string(data)
+	return denied, nil
+}
+
+func (c *impl) response_headers(response ref.Val) ref.Val {
+	if response, err := utils.ConvertToNative[*CheckResponse](response); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(response.Headers())
+	}
+}
+
+func (c *impl) response_response_headers(response ref.Val) ref.Val {
+	if response, err := utils.ConvertToNative[*CheckResponse](response); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(response.ResponseHeaders())
+	}
+}
+
+// setHeader returns a copy of h with the values of key replaced by value.
+func setHeader(h header, key, value string) header {
+	out := addHeader(h, key, value)
+	out[textproto.CanonicalMIMEHeaderKey(key)] = []string{value}
+	return out
+}
+
+func (c *impl) redirect(location ref.Val) ref.Val {
+	return c.redirect_status(location, types.Int(http.StatusFound))
+}
+
+func (c *impl) redirect_status(location ref.Val, status ref.Val) ref.Val {
+	if location, err := utils.ConvertToNative[string](location); err != nil {
+		return types.WrapErr(err)
+	} else if status, err := utils.ConvertToNative[int](status); err != nil {
+		return types.WrapErr(err)
+	} else if status < 300 || status > 399 {
+		return types.NewErr("invalid http redirect status code: %d", status)
+	} else {
+		r := CheckResponseDenied{
+			Status: status,
+			Header: header{"Location": {location}},
+		}
+		return c.NativeToValue(r)
+	}
+}
+
+func (c *impl) denied_with_content_type(denied ref.Val, contentType ref.Val) ref.Val {
+	if denied, err := utils.ConvertToNative[CheckResponseDenied](denied); err != nil {
+		return types.WrapErr(err)
+	} else if contentType, err := utils.ConvertToNative[string](contentType); err != nil {
+		return types.WrapErr(err)
+	} else {
+		denied.Header = setHeader(denied.Header, "Content-Type", contentType)
+		return c.NativeToValue(denied)
+	}
+}
+
+func (c *impl) denied_with_json_body(denied ref.Val, body ref.Val) ref.Val {
+	if denied, err := utils.ConvertToNative[CheckResponseDenied](denied); err != nil {
+		return types.WrapErr(err)
+	} else if body, err := utils.ConvertToNative[*structpb.Value](body); err != nil {
+		return types.WrapErr(err)
+	} else if data, err := json.Marshal(body.AsInterface()); err != nil {
+		return types.WrapErr(err)
+	} else {
+		denied.Body = string(data)
+		denied.Header = setHeader(denied.Header, "Content-Type", "application/json")
+		return c.NativeToValue(denied)
+	}
+}
+
+func (c *impl) denied_with_problem_details(denied ref.Val, problem ref.Val) ref.Val {
+	if denied, err := utils.ConvertToNative[CheckResponseDenied](denied); err != nil {
+		return types.WrapErr(err)
+	} else if problem, err := utils.ConvertToNative[*structpb.Struct](problem); err != nil {
+		return types.WrapErr(err)
+	} else {
+		// the problem status must match the response status
+		if denied.Status == 0 {
+			denied.Status = http.StatusForbidden
+		}
+		details := problem.AsMap()
+		details["status"] = denied.Status
+		if _, ok := details["title"]; !ok {
+			details["title"] = http.StatusText(denied.Status)
+		}
+		if _, ok := details["detail"]; !ok && denied.Reason != "" {
+			details["detail"] = denied.Reason
+		}
+		if data, err := json.Marshal(details); err != nil {
+			return types.WrapErr(err)
+		} else {
+			denied.Body = string(data)
+			denied.Header = setHeader(denied.Header, "Content-Type", ProblemContentType)
+			return c.NativeToValue(denied)
+		}
+	}
+}
+
+func (c *impl) denied_with_challenge(denied ref.Val, challenge ref.Val) ref.Val {
+	if denied, err := utils.ConvertToNative[CheckResponseDenied](denied); err != nil {
+		return types.WrapErr(err)
+	} else if challenge, err := utils.ConvertToNative[string](challenge); err != nil {
+		return types.WrapErr(err)
+	} else if denied, err := setStatus(denied, http.StatusUnauthorized); err != nil {
+		return types.WrapErr(err)
+	} else {
+		denied.Header = addHeader(denied.Header, "WWW-Authenticate", challenge)
+		return c.NativeToValue(denied)
+	}
+}
+
+func (c *impl) denied_with_retry_after_int(denied ref.Val, seconds ref.Val) ref.Val {
+	if seconds, err := utils.ConvertToNative[int](seconds); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.retry_after(denied, seconds)
+	}
+}
+
+func (c *impl) denied_with_retry_after_duration(denied ref.Val, duration ref.Val) ref.Val {
+	if duration, err := utils.ConvertToNative[time.Duration](duration); err != nil {
+		return types.WrapErr(err)
+	} else {
+		// round up, retrying early would be denied again
+		return c.retry_after(denied, int((duration+time.Second-1)/time.Second))
+	}
+}
+
+func (c *impl) retry_after(denied ref.Val, seconds int) ref.Val {
+	if denied, err := utils.ConvertToNative[CheckResponseDenied](denied); err != nil {
+		return types.WrapErr(err)
+	} else if seconds < 0 {
+		return types.NewErr("invalid retry after delay: %d", seconds)
+	} else if denied, err := setStatus(denied, http.StatusTooManyRequests); err != nil {
+		return types.WrapErr(err)
+	} else {
+		denied.Header = setHeader(denied.Header, "Retry-After", strconv.Itoa(seconds))
+		return c.NativeToValue(denied)
+	}
+}
@@ -49,12 +49,51 @@ func (c *lib) extendEnv(env *cel.Env) (*cel.Env, error) {
 		"http.Denied": {
 			cel.Overload("http_denied_string", []*cel.Type{cel.StringType}, ResponseDeniedType, cel.UnaryBinding(impl.denied)),
 		},
+		"http.Redirect": {
+			cel.Overload("http_redirect_string", []*cel.Type{cel.StringType}, ResponseDeniedType, cel.UnaryBinding(impl.redirect)),
+			cel.Overload("http_redirect_string_int", []*cel.Type{cel.StringType, cel.IntType}, ResponseDeniedType, cel.BinaryBinding(impl.redirect_status)),
+		},
 		"Header": {
 			cel.MemberOverload("http_get_header_string", []*cel.Type{RequestAttributesType, cel.StringType}, types.NewListType(cel.StringType), cel.BinaryBinding(impl.get_header)),
 		},
 		"QueryParam": {
 			cel.MemberOverload("http_get_queryparam_string", []*cel.Type{RequestAttributesType, cel.StringType}, types.NewListType(cel.StringType), cel.BinaryBinding(impl.get_queryparam)),
 		},
+		"WithHeader": {
+			cel.MemberOverload("http_ok_with_header_string_string", []*cel.Type{ResponseOkType, cel.StringType, cel.StringType}, ResponseOkType, cel.FunctionBinding(impl.ok_with_header)),
+			cel.MemberOverload("http_denied_with_header_string_string", []*cel.Type{ResponseDeniedType, cel.StringType, cel.StringType}, ResponseDeniedType, cel.FunctionBinding(impl.denied_with_header)),
+		},
+		"WithResponseHeader": {
+			cel.MemberOverload("http_ok_with_response_header_string_string", []*cel.Type{ResponseOkType, cel.StringType, cel.StringType}, ResponseOkType, cel.FunctionBinding(impl.ok_with_response_header)),
+		},
+		"WithBody": {
+			cel.MemberOverload("http_denied_with_body_string", []*cel.Type{ResponseDeniedType, cel.StringType}, ResponseDeniedType, cel.BinaryBinding(impl.denied_with_body)),
+		},
+		"WithStatus": {
+			cel.MemberOverload("http_denied_with_status_int", []*cel.Type{ResponseDeniedType, cel.IntType}, ResponseDeniedType, cel.BinaryBinding(impl.denied_with_status)),
+		},
+		"WithContentType": {
+			cel.MemberOverload("http_denied_with_content_type_string", []*cel.Type{ResponseDeniedType, cel.StringType}, ResponseDeniedType, cel.BinaryBinding(impl.denied_with_content_type)),
+		},
+		"WithJsonBody": {
+			cel.MemberOverload("http_denied_with_json_body_dyn", []*cel.Type{ResponseDeniedType, cel.DynType}, ResponseDeniedType, cel.BinaryBinding(impl.denied_with_json_body)),
+		},
+		"WithProblemDetails": {
+			cel.MemberOverload("http_denied_with_problem_details_map", []*cel.Type{ResponseDeniedType, cel.MapType(cel.StringType, cel.DynType)}, ResponseDeniedType, cel.BinaryBinding(impl.denied_with_problem_details)),
+		},
+		"WithChallenge": {
+			cel.MemberOverload("http_denied_with_challenge_string", []*cel.Type{ResponseDeniedType, cel.StringType}, ResponseDeniedType, cel.BinaryBinding(impl.denied_with_challenge)),
+		},
+		"WithRetryAfter": {
+			cel.MemberOverload("http_denied_with_retry_after_int", []*cel.Type{ResponseDeniedType, cel.IntType}, ResponseDeniedType, cel.BinaryBinding(impl.denied_with_retry_after_int)),
+			cel.MemberOverload("http_denied_with_retry_after_duration", []*cel.Type{ResponseDeniedType, cel.DurationType}, ResponseDeniedType, cel.BinaryBinding(impl.denied_with_retry_after_duration)),
+		},
+		"Headers": {
+			cel.MemberOverload("http_response_headers", []*cel.Type{ResponseType}, types.NewMapType(cel.StringType, types.NewListType(cel.StringType)), cel.UnaryBinding(impl.response_headers)),
+		},
+		"ResponseHeaders": {
+			cel.MemberOverload("http_response_response_headers", []*cel.Type{ResponseType}, types.NewMapType(cel.StringType, types.NewListType(cel.StringType)), cel.UnaryBinding(impl.response_response_headers)),
+		},
 		"Response": {
 			cel.MemberOverload("http_response_ok", []*cel.Type{ResponseOkType}, ResponseType, cel.UnaryBinding(impl.response_ok)),
 			cel.MemberOverload("http_response_denied", []*cel.Type{ResponseDeniedType}, ResponseType, cel.UnaryBinding(impl.response_denied)),
@@ -3,6 +3,7 @@ package http
 import (
 	"io"
 	"net/http"
+	"net/textproto"
 
 	"github.com/google/cel-go/common/types"
 )
@@ -15,6 +16,9 @@ var (
 	ResponseDeniedType    = types.NewObjectType("http.CheckResponseDenied")
 )
 
+// ProblemContentType is the content type of RFC 7807 problem details responses
+const ProblemContentType = "application/problem+json"
+
 type (
 	header = map[string][]string
 	query  = map[string][]string
@@ -37,10 +41,21 @@ type CheckRequest struct {
 	Attributes CheckRequestAttributes `cel:"attributes"`
 }
 
-type CheckResponseOk struct{}
+type CheckResponseOk struct {
+	// Header holds the headers passed to the upstream service
+	Header header `cel:"header"`
+	// ResponseHeader holds the headers added to the response sent to the client
+	ResponseHeader header `cel:"responseHeader"`
+}
 
 type CheckResponseDenied struct {
 	Reason string `cel:"reason"`
+	// Status overrides the status code of the denied response when not zero
+	Status int `cel:"status"`
+	// Header holds the headers of the denied response
+	Header header `cel:"header"`
+	// Body overrides the body of the denied response (the reason by default) when not empty
+	Body string `cel:"body"`
 }
 
 type CheckResponse struct {
@@ -48,6 +63,35 @@ type CheckResponse struct {
 	Denied *CheckResponseDenied `cel:"denied"`
 }
 
+// Headers returns the headers of the http response carrying the decision, the upstream headers
+// when allowed (forward auth proxies copy the upstream headers they are configured with to the
+// upstream request) or the denied response headers.
+func (r *CheckResponse) Headers() map[string][]string {
+	if r.Ok != nil {
+		return canonicalHeader(r.Ok.Header)
+	} else if r.Denied != nil {
+		return canonicalHeader(r.Denied.Header)
+	}
+	return map[string][]string{}
+}
+
+// ResponseHeaders returns the headers intended for the client when allowed.
+func (r *CheckResponse) ResponseHeaders() map[string][]string {
+	if r.Ok != nil {
+		return canonicalHeader(r.Ok.ResponseHeader)
+	}
+	return map[string][]string{}
+}
+
+func canonicalHeader(h header) map[string][]string {
+	out := map[string][]string{}
+	for k, v := range h {
+		k = textproto.CanonicalMIMEHeaderKey(k)
+		out[k] = append(out[k], v...)
+	}
+	return out
+}
+
 func NewRequest(r *http.Request) (CheckRequest, error) {
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
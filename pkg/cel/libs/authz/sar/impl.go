@@ -0,0 +1,38 @@
+package sar
+
+import (
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
+)
+
+type impl struct {
+	types.Adapter
+}
+
+func (c *impl) allowed() ref.Val {
+	return c.NativeToValue(&CheckResponse{Allowed: true})
+}
+
+func (c *impl) no_opinion() ref.Val {
+	return c.NativeToValue(&CheckResponse{})
+}
+
+func (c *impl) denied(reason ref.Val) ref.Val {
+	if reason, err := utils.ConvertToNative[string](reason); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return c.NativeToValue(&CheckResponse{Denied: true, Reason: reason})
+	}
+}
+
+func (c *impl) response_with_reason(response ref.Val, reason ref.Val) ref.Val {
+	if response, err := utils.ConvertToNative[CheckResponse](response); err != nil {
+		return types.WrapErr(err)
+	} else if reason, err := utils.ConvertToNative[string](reason); err != nil {
+		return types.WrapErr(err)
+	} else {
+		response.Reason = reason
+		return c.NativeToValue(&response)
+	}
+}
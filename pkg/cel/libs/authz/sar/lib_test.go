@@ -0,0 +1,67 @@
+package sar_test
+
+import (
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+func TestLib(t *testing.T) {
+	request := sar.NewRequest(authorizationv1.SubjectAccessReviewSpec{
+		User:   "jane",
+		Groups: []string{"developers"},
+		ResourceAttributes: &authorizationv1.ResourceAttributes{
+			Namespace: "kube-system",
+			Verb:      "get",
+			Resource:  "secrets",
+		},
+	})
+	tests := []struct {
+		name   string
+		source string
+		want   sar.CheckResponse
+	}{{
+		name:   "allowed",
+		source: `sar.Allowed()`,
+		want:   sar.CheckResponse{Allowed: true},
+	}, {
+		name:   "no opinion",
+		source: `sar.NoOpinion()`,
+		want:   sar.CheckResponse{},
+	}, {
+		name: "denied",
+		source: `
+		has(object.resourceAttributes) && object.resourceAttributes.namespace == "kube-system" && !("admins" in object.groups)
+			? sar.Denied("kube-system is restricted to admins")
+			: sar.Allowed()
+		`,
+		want: sar.CheckResponse{Denied: true, Reason: "kube-system is restricted to admins"},
+	}, {
+		name:   "with reason",
+		source: `sar.Allowed().WithReason(object.user + " can " + object.resourceAttributes.verb)`,
+		want:   sar.CheckResponse{Allowed: true, Reason: "jane can get"},
+	}, {
+		name:   "non resource",
+		source: `has(object.nonResourceAttributes) ? sar.Denied("") : sar.NoOpinion()`,
+		want:   sar.CheckResponse{},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := cel.NewEnv(sar.Lib(), cel.Variable("object", sar.RequestType))
+			assert.NoError(t, err)
+			ast, issues := env.Compile(tt.source)
+			assert.Nil(t, issues)
+			prog, err := env.Program(ast)
+			assert.NoError(t, err)
+			out, _, err := prog.Eval(map[string]any{"object": &request})
+			assert.NoError(t, err)
+			got, err := utils.ConvertToNative[*sar.CheckResponse](out)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
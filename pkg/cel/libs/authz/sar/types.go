@@ -0,0 +1,75 @@
+package sar
+
+import (
+	"github.com/google/cel-go/common/types"
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+var (
+	RequestType               = types.NewObjectType("sar.CheckRequest")
+	ResourceAttributesType    = types.NewObjectType("sar.ResourceAttributes")
+	NonResourceAttributesType = types.NewObjectType("sar.NonResourceAttributes")
+	ResponseType              = types.NewObjectType("sar.CheckResponse")
+)
+
+type ResourceAttributes struct {
+	Namespace   string `cel:"namespace"`
+	Verb        string `cel:"verb"`
+	Group       string `cel:"group"`
+	Version     string `cel:"version"`
+	Resource    string `cel:"resource"`
+	Subresource string `cel:"subresource"`
+	Name        string `cel:"name"`
+}
+
+type NonResourceAttributes struct {
+	Path string `cel:"path"`
+	Verb string `cel:"verb"`
+}
+
+type CheckRequest struct {
+	User                  string                 `cel:"user"`
+	UID                   string                 `cel:"uid"`
+	Groups                []string               `cel:"groups"`
+	Extra                 map[string][]string    `cel:"extra"`
+	ResourceAttributes    *ResourceAttributes    `cel:"resourceAttributes"`
+	NonResourceAttributes *NonResourceAttributes `cel:"nonResourceAttributes"`
+}
+
+// CheckResponse is the decision of a policy, the request is allowed if Allowed is true,
+// denied if Denied is true, when both are false the policy has no opinion.
+type CheckResponse struct {
+	Allowed bool   `cel:"allowed"`
+	Denied  bool   `cel:"denied"`
+	Reason  string `cel:"reason"`
+}
+
+func NewRequest(spec authorizationv1.SubjectAccessReviewSpec) CheckRequest {
+	request := CheckRequest{
+		User:   spec.User,
+		UID:    spec.UID,
+		Groups: spec.Groups,
+		Extra:  map[string][]string{},
+	}
+	for key, values := range spec.Extra {
+		request.Extra[key] = values
+	}
+	if attributes := spec.ResourceAttributes; attributes != nil {
+		request.ResourceAttributes = &ResourceAttributes{
+			Namespace:   attributes.Namespace,
+			Verb:        attributes.Verb,
+			Group:       attributes.Group,
+			Version:     attributes.Version,
+			Resource:    attributes.Resource,
+			Subresource: attributes.Subresource,
+			Name:        attributes.Name,
+		}
+	}
+	if attributes := spec.NonResourceAttributes; attributes != nil {
+		request.NonResourceAttributes = &NonResourceAttributes{
+			Path: attributes.Path,
+			Verb: attributes.Verb,
+		}
+	}
+	return request
+}
@@ -0,0 +1,124 @@
+package functions
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+)
+
+type impl struct {
+	parameters []string
+	argTypes   []*cel.Type
+	returnType *cel.Type
+	program    cel.Program
+	// references holds the variables referenced by the body, parameters excluded
+	references []string
+	err        error
+}
+
+// overloadID returns the id of the overload declared for a function.
+func overloadID(name string) string {
+	return "lib_" + name
+}
+
+// compile compiles the function body in an environment declaring its parameters.
+func compile(env *cel.Env, function v1alpha1.Function) (*impl, error) {
+	out := impl{}
+	var variables []cel.EnvOption
+	for _, parameter := range function.Parameters {
+		t, err := parseType(env, parameter.Type)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", parameter.Name, err)
+		}
+		out.parameters = append(out.parameters, parameter.Name)
+		out.argTypes = append(out.argTypes, t)
+		variables = append(variables, cel.Variable(parameter.Name, t))
+	}
+	env, err := env.Extend(variables...)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(function.Expression)
+	if err := issues.Err(); err != nil {
+		return nil, err
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	out.returnType = ast.OutputType()
+	out.program = program
+	for _, ref := range ast.NativeRep().ReferenceMap() {
+		// functions have overloads, variables don't
+		if len(ref.OverloadIDs) == 0 && ref.Name != "" && !slices.Contains(out.parameters, ref.Name) && !slices.Contains(out.references, ref.Name) {
+			out.references = append(out.references, ref.Name)
+		}
+	}
+	return &out, nil
+}
+
+// failed returns an implementation always returning the given error.
+func failed(function v1alpha1.Function, err error) *impl {
+	out := impl{
+		returnType: types.DynType,
+		err:        err,
+	}
+	for range function.Parameters {
+		out.argTypes = append(out.argTypes, types.DynType)
+	}
+	return &out
+}
+
+func (i *impl) call(args ...ref.Val) ref.Val {
+	if i.err != nil {
+		return types.WrapErr(i.err)
+	}
+	// first argument is the lib receiver, it carries the receivers bound for the request
+	lib, _ := args[0].(Library)
+	args = args[1:]
+	bindings := lib.bindings()
+	activation := make(map[string]any, len(args)+len(bindings))
+	maps.Copy(activation, bindings)
+	for index, parameter := range i.parameters {
+		activation[parameter] = args[index]
+	}
+	out, _, err := i.program.Eval(activation)
+	if err != nil {
+		return types.WrapErr(err)
+	}
+	return out
+}
+
+var primitiveTypes = map[string]*cel.Type{
+	"":          types.DynType,
+	"dyn":       types.DynType,
+	"bool":      types.BoolType,
+	"int":       types.IntType,
+	"uint":      types.UintType,
+	"double":    types.DoubleType,
+	"string":    types.StringType,
+	"bytes":     types.BytesType,
+	"duration":  types.DurationType,
+	"timestamp": types.TimestampType,
+	"list":      types.NewListType(types.DynType),
+	"map":       types.NewMapType(types.DynType, types.DynType),
+}
+
+// parseType resolves a type name, either a primitive type or a type known to the environment.
+func parseType(env *cel.Env, name string) (*cel.Type, error) {
+	if t, ok := primitiveTypes[name]; ok {
+		return t, nil
+	}
+	if t, ok := env.CELTypeProvider().FindStructType(name); ok {
+		// the provider returns the type of the type
+		if params := t.Parameters(); len(params) == 1 {
+			return params[0], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown type: %s", name)
+}
@@ -0,0 +1,75 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+)
+
+type lib struct {
+	functions []v1alpha1.Function
+}
+
+// Lib exposes the given functions as members of the `lib` variable.
+// Function bodies are compiled against the environment the library is added to,
+// it should be registered after all the other libraries.
+func Lib(functions ...v1alpha1.Function) cel.EnvOption {
+	// create the cel lib env option
+	return cel.Lib(&lib{
+		functions: functions,
+	})
+}
+
+func (*lib) LibraryName() string {
+	return "kyverno.functions"
+}
+
+func (l *lib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Variable("lib", LibraryType),
+		// extend environment with function overloads
+		l.extendEnv,
+	}
+}
+
+func (l *lib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{
+		cel.Globals(
+			map[string]any{
+				"lib": Library{},
+			},
+		),
+	}
+}
+
+// References returns the variables referenced by the bodies of the given functions (parameters excluded),
+// indexed by the id of the overload declared for each function. Bodies are compiled against env,
+// functions that fail to compile reference nothing.
+func References(env *cel.Env, functions ...v1alpha1.Function) map[string][]string {
+	out := map[string][]string{}
+	for _, function := range functions {
+		if impl, err := compile(env, function); err == nil {
+			out[overloadID(function.Name)] = impl.references
+		}
+	}
+	return out
+}
+
+func (l *lib) extendEnv(env *cel.Env) (*cel.Env, error) {
+	// build our function overloads
+	options := make([]cel.EnvOption, 0, len(l.functions))
+	for _, function := range l.functions {
+		impl, err := compile(env, function)
+		if err != nil {
+			// declare the function anyway so that policies compile and evaluation reports the error
+			impl = failed(function, fmt.Errorf("failed to compile function %s: %w", function.Name, err))
+		}
+		argTypes := append([]*cel.Type{LibraryType}, impl.argTypes...)
+		options = append(options, cel.Function(function.Name,
+			cel.MemberOverload(overloadID(function.Name), argTypes, impl.returnType, cel.FunctionBinding(impl.call)),
+		))
+	}
+	// extend environment with our function overloads
+	return env.Extend(options...)
+}
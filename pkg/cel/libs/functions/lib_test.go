@@ -0,0 +1,95 @@
+package functions_test
+
+import (
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLib(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    any
+		wantErr bool
+	}{{
+		name:   "typed parameters",
+		source: `lib.bearerToken({"authorization": "Bearer abc"})`,
+		want:   "abc",
+	}, {
+		name:   "dyn parameters",
+		source: `lib.normalize(" X-Tenant ")`,
+		want:   "x-tenant",
+	}, {
+		name:   "no parameters",
+		source: `lib.answer() + 1`,
+		want:   int64(43),
+	}, {
+		name:    "invalid body",
+		source:  `lib.broken("foo")`,
+		wantErr: true,
+	}}
+	env, err := cel.NewEnv(
+		cel.OptionalTypes(),
+		ext.Strings(),
+		functions.Lib(
+			v1alpha1.Function{
+				Name:       "bearerToken",
+				Parameters: []v1alpha1.FunctionParameter{{Name: "headers", Type: "map"}},
+				Expression: `headers[?"authorization"].orValue("").split(" ")[1]`,
+			},
+			v1alpha1.Function{
+				Name:       "normalize",
+				Parameters: []v1alpha1.FunctionParameter{{Name: "value"}},
+				Expression: `string(value).trim().lowerAscii()`,
+			},
+			v1alpha1.Function{
+				Name:       "answer",
+				Expression: `42`,
+			},
+			v1alpha1.Function{
+				Name:       "broken",
+				Parameters: []v1alpha1.FunctionParameter{{Name: "value", Type: "unknown.Type"}},
+				Expression: `value`,
+			},
+		),
+	)
+	assert.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, issues := env.Compile(tt.source)
+			assert.NoError(t, issues.Err())
+			prog, err := env.Program(ast)
+			assert.NoError(t, err)
+			out, _, err := prog.Eval(map[string]any{})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.Value())
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := functions.NewRegistry()
+	assert.Empty(t, registry.Functions())
+	registry.Store("b", v1alpha1.Function{Name: "foo", Expression: "2"}, v1alpha1.Function{Name: "bar", Expression: "3"})
+	registry.Store("a", v1alpha1.Function{Name: "foo", Expression: "1"})
+	assert.Equal(t, []v1alpha1.Function{
+		{Name: "foo", Expression: "1"},
+		{Name: "bar", Expression: "3"},
+	}, registry.Functions())
+	generation := registry.Generation()
+	registry.Delete("a")
+	assert.Greater(t, registry.Generation(), generation)
+	assert.Equal(t, []v1alpha1.Function{
+		{Name: "foo", Expression: "2"},
+		{Name: "bar", Expression: "3"},
+	}, registry.Functions())
+}
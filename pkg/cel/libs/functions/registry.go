@@ -0,0 +1,69 @@
+package functions
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+)
+
+// Registry holds the functions declared by function libraries.
+// The generation is incremented every time the registry changes so that policies can be recompiled.
+type Registry struct {
+	lock       sync.RWMutex
+	libraries  map[string][]v1alpha1.Function
+	generation int64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		libraries: map[string][]v1alpha1.Function{},
+	}
+}
+
+var Default = sync.OnceValue(NewRegistry)
+
+// Store registers (or replaces) the functions of the given library.
+func (r *Registry) Store(library string, functions ...v1alpha1.Function) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.libraries[library] = functions
+	r.generation++
+}
+
+// Delete removes the functions of the given library.
+func (r *Registry) Delete(library string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.libraries[library]; ok {
+		delete(r.libraries, library)
+		r.generation++
+	}
+}
+
+// Functions returns the registered functions, libraries are processed in name order
+// and when multiple libraries declare the same function the first one wins.
+func (r *Registry) Functions() []v1alpha1.Function {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	var out []v1alpha1.Function
+	seen := map[string]struct{}{}
+	for _, library := range slices.Sorted(maps.Keys(r.libraries)) {
+		for _, function := range r.libraries[library] {
+			if _, ok := seen[function.Name]; ok {
+				continue
+			}
+			seen[function.Name] = struct{}{}
+			out = append(out, function)
+		}
+	}
+	return out
+}
+
+// Generation returns a counter incremented every time the registry changes.
+func (r *Registry) Generation() int64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.generation
+}
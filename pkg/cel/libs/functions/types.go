@@ -0,0 +1,63 @@
+package functions
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+var LibraryType = types.NewOpaqueType("functions.Library")
+
+// Library is the value bound to the `lib` variable, it serves as the receiver of library functions.
+// Function bodies are evaluated with the receivers it carries (per request receivers for example),
+// other variables resolve to the values bound when the environment was created.
+type Library struct {
+	// receivers is a pointer so that libraries stay comparable
+	receivers *receivers
+}
+
+type receivers struct {
+	values map[string]any
+}
+
+// NewLibrary returns a library evaluating function bodies with the given receivers.
+func NewLibrary(values map[string]any) Library {
+	return Library{receivers: &receivers{values: values}}
+}
+
+// bindings returns the receivers bound to the variables of function bodies.
+func (l Library) bindings() map[string]any {
+	if l.receivers == nil {
+		return nil
+	}
+	return l.receivers.values
+}
+
+func (l Library) ConvertToNative(typeDesc reflect.Type) (any, error) {
+	return nil, fmt.Errorf("type conversion error from %s to %v", LibraryType, typeDesc)
+}
+
+func (l Library) ConvertToType(typeVal ref.Type) ref.Val {
+	switch typeVal {
+	case LibraryType:
+		return l
+	case types.TypeType:
+		return LibraryType
+	}
+	return types.NewErr("type conversion error from %s to %s", LibraryType, typeVal)
+}
+
+func (l Library) Equal(other ref.Val) ref.Val {
+	_, ok := other.(Library)
+	return types.Bool(ok)
+}
+
+func (l Library) Type() ref.Type {
+	return LibraryType
+}
+
+func (l Library) Value() any {
+	return l
+}
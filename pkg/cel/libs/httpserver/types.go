@@ -11,5 +11,8 @@ var (
 type HttpResponse struct {
 	Status int                 `cel:"status"`
 	Header map[string][]string `cel:"header"`
-	Body   []byte              `cel:"body"`
+	// ResponseHeader holds the headers intended for the client, kept apart from Header
+	// so that output expressions can rename or drop them
+	ResponseHeader map[string][]string `cel:"responseHeader"`
+	Body           []byte              `cel:"body"`
 }
@@ -0,0 +1,25 @@
+package ipset
+
+import (
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
+)
+
+type impl struct {
+	types.Adapter
+}
+
+func (c *impl) contains_string_string(args ...ref.Val) ref.Val {
+	if ipset, err := utils.ConvertToNative[IPSet](args[0]); err != nil {
+		return types.WrapErr(err)
+	} else if name, err := utils.ConvertToNative[string](args[1]); err != nil {
+		return types.WrapErr(err)
+	} else if ip, err := utils.ConvertToNative[string](args[2]); err != nil {
+		return types.WrapErr(err)
+	} else if contains, err := ipset.Contains(name, ip); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return types.Bool(contains)
+	}
+}
@@ -0,0 +1,64 @@
+package ipset
+
+import (
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+)
+
+type lib struct {
+	ipset IPSet
+}
+
+func Lib(ipset IPSetImpl) cel.EnvOption {
+	// create the cel lib env option
+	return cel.Lib(&lib{
+		ipset: IPSet{ipset},
+	})
+}
+
+func (*lib) LibraryName() string {
+	return "kyverno.ipset"
+}
+
+func (l *lib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Variable("ipset", IPSetType),
+		// register native types
+		ext.NativeTypes(reflect.TypeFor[IPSet]()),
+		// extend environment with function overloads
+		l.extendEnv,
+	}
+}
+
+func (l *lib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{
+		cel.Globals(
+			map[string]any{
+				"ipset": l.ipset,
+			},
+		),
+	}
+}
+
+func (*lib) extendEnv(env *cel.Env) (*cel.Env, error) {
+	// get env type adapter
+	adapter := env.CELTypeAdapter()
+	// create implementation with adapter
+	impl := impl{adapter}
+	// build our function overloads
+	libraryDecls := map[string][]cel.FunctionOpt{
+		"Contains": {
+			cel.MemberOverload("ipset_contains_string_string", []*cel.Type{IPSetType, types.StringType, types.StringType}, types.BoolType, cel.FunctionBinding(impl.contains_string_string)),
+		},
+	}
+	// create env options corresponding to our function overloads
+	options := []cel.EnvOption{}
+	for name, overloads := range libraryDecls {
+		options = append(options, cel.Function(name, overloads...))
+	}
+	// extend environment with our function overloads
+	return env.Extend(options...)
+}
@@ -0,0 +1,63 @@
+package ipset_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/ipset"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockIPSet map[string][]string
+
+func (m mockIPSet) Contains(name string, ip string) (bool, error) {
+	ips, ok := m[name]
+	if !ok {
+		return false, errors.New("ip set not found")
+	}
+	for _, candidate := range ips {
+		if candidate == ip {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    bool
+		wantErr bool
+	}{{
+		name:   "found",
+		source: `ipset.Contains("blocklist", "10.0.0.1")`,
+		want:   true,
+	}, {
+		name:   "not found",
+		source: `ipset.Contains("blocklist", "10.0.0.2")`,
+		want:   false,
+	}, {
+		name:    "unknown set",
+		source:  `ipset.Contains("unknown", "10.0.0.1")`,
+		wantErr: true,
+	}}
+	env, err := cel.NewEnv(ipset.Lib(mockIPSet{"blocklist": {"10.0.0.1"}}))
+	assert.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, issues := env.Compile(tt.source)
+			assert.Nil(t, issues)
+			prog, err := env.Program(ast)
+			assert.NoError(t, err)
+			out, _, err := prog.Eval(map[string]any{})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.Value())
+		})
+	}
+}
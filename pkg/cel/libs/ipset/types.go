@@ -0,0 +1,15 @@
+package ipset
+
+import (
+	"github.com/google/cel-go/common/types"
+)
+
+var IPSetType = types.NewOpaqueType("ipset.IPSet")
+
+type IPSetImpl interface {
+	Contains(name string, ip string) (bool, error)
+}
+
+type IPSet struct {
+	IPSetImpl
+}
@@ -16,10 +16,12 @@ func (i *impl) unmarshal(json ref.Val, value ref.Val) ref.Val {
 	} else if value, err := utils.ConvertToNative[string](value); err != nil {
 		return types.WrapErr(err)
 	} else {
-		if value, err := json.Unmarshal([]byte(value)); err != nil {
-			return types.WrapErr(err)
-		} else {
-			return i.NativeToValue(value)
-		}
+		return json.Memo.Call("json.Unmarshal", func() ref.Val {
+			if value, err := json.Unmarshal([]byte(value)); err != nil {
+				return types.WrapErr(err)
+			} else {
+				return i.NativeToValue(value)
+			}
+		}, value)
 	}
 }
@@ -8,14 +8,17 @@ import (
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/ext"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
 )
 
 type mockJson struct {
-	ret any
-	err error
+	ret   any
+	err   error
+	calls int
 }
 
 func (m *mockJson) Unmarshal(b []byte) (any, error) {
+	m.calls++
 	return m.ret, m.err
 }
 
@@ -41,7 +44,7 @@ func TestImplUnmarshal(t *testing.T) {
 	tests := []testCase{
 		{
 			name:         "success",
-			jsonVal:      Json{&mockJson{ret: map[string]any{"foo": "bar"}, err: nil}},
+			jsonVal:      Json{JsonImpl: &mockJson{ret: map[string]any{"foo": "bar"}, err: nil}},
 			valueVal:     `{"foo":"bar"}`,
 			expectErr:    false,
 			expectResult: map[string]any{"foo": "bar"},
@@ -54,13 +57,13 @@ func TestImplUnmarshal(t *testing.T) {
 		},
 		{
 			name:      "value convert error",
-			jsonVal:   Json{&mockJson{ret: nil, err: nil}},
+			jsonVal:   Json{JsonImpl: &mockJson{ret: nil, err: nil}},
 			valueVal:  12345,
 			expectErr: true,
 		},
 		{
 			name:      "unmarshal error",
-			jsonVal:   Json{&mockJson{ret: nil, err: errors.New("unmarshal failed")}},
+			jsonVal:   Json{JsonImpl: &mockJson{ret: nil, err: errors.New("unmarshal failed")}},
 			valueVal:  "bad json",
 			expectErr: true,
 		},
@@ -72,20 +75,20 @@ func TestImplUnmarshal(t *testing.T) {
 		},
 		{
 			name:      "value is nil",
-			jsonVal:   Json{&mockJson{ret: map[string]any{"foo": "bar"}, err: nil}},
+			jsonVal:   Json{JsonImpl: &mockJson{ret: map[string]any{"foo": "bar"}, err: nil}},
 			valueVal:  nil,
 			expectErr: true,
 		},
 		{
 			name:         "value is empty string",
-			jsonVal:      Json{&mockJson{ret: map[string]any{}, err: nil}},
+			jsonVal:      Json{JsonImpl: &mockJson{ret: map[string]any{}, err: nil}},
 			valueVal:     "",
 			expectErr:    false,
 			expectResult: map[string]any{},
 		},
 		{
 			name:         "json is empty struct",
-			jsonVal:      Json{&mockJson{ret: map[string]any{}, err: nil}},
+			jsonVal:      Json{JsonImpl: &mockJson{ret: map[string]any{}, err: nil}},
 			valueVal:     `{"foo":"bar"}`,
 			expectErr:    false,
 			expectResult: map[string]any{},
@@ -114,3 +117,23 @@ func TestImplUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestImplUnmarshalMemo(t *testing.T) {
+	env, err := cel.NewEnv(
+		ext.NativeTypes(reflect.TypeFor[Json]()),
+	)
+	if err != nil {
+		t.Fatalf("failed to create CEL environment: %v", err)
+	}
+	adapter := env.CELTypeAdapter()
+	i := &impl{adapter}
+	mock := &mockJson{ret: map[string]any{"foo": "bar"}}
+	jsonVal := adapter.NativeToValue(Json{JsonImpl: mock, Memo: memo.New()})
+	for range 3 {
+		i.unmarshal(jsonVal, types.String(`{"foo":"bar"}`))
+	}
+	i.unmarshal(jsonVal, types.String(`{"foo":"baz"}`))
+	if mock.calls != 2 {
+		t.Errorf("expected 2 calls, got %d", mock.calls)
+	}
+}
@@ -15,7 +15,7 @@ type lib struct {
 func Lib(json JsonImpl) cel.EnvOption {
 	// create the cel lib env option
 	return cel.Lib(&lib{
-		json: Json{json},
+		json: Json{JsonImpl: json},
 	})
 }
 
@@ -2,6 +2,7 @@ package json
 
 import (
 	"github.com/google/cel-go/common/types"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
 )
 
 var JsonType = types.NewOpaqueType("json.Json")
@@ -12,4 +13,6 @@ type JsonImpl interface {
 
 type Json struct {
 	JsonImpl
+	// Memo memoizes unmarshalled documents for the duration of a request when set
+	Memo *memo.Memo
 }
@@ -13,14 +13,18 @@ type impl struct {
 	types.Adapter
 }
 
-func (c *impl) fetch(from ref.Val) ref.Val {
-	if from, err := utils.ConvertToNative[string](from); err != nil {
+func (c *impl) fetch(jwks ref.Val, from ref.Val) ref.Val {
+	if jwks, err := utils.ConvertToNative[Jwks](jwks); err != nil {
+		return types.WrapErr(err)
+	} else if from, err := utils.ConvertToNative[string](from); err != nil {
 		return types.WrapErr(err)
 	} else {
-		set, err := jwk.Fetch(context.Background(), from)
-		if err != nil {
-			return types.WrapErr(err)
-		}
-		return c.NativeToValue(Set{set})
+		return jwks.Memo.Call("jwks.Fetch", func() ref.Val {
+			set, err := jwk.Fetch(context.Background(), from)
+			if err != nil {
+				return types.WrapErr(err)
+			}
+			return c.NativeToValue(Set{set})
+		}, from)
 	}
 }
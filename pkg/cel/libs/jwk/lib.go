@@ -21,15 +21,22 @@ func (*lib) LibraryName() string {
 
 func (c *lib) CompileOptions() []cel.EnvOption {
 	return []cel.EnvOption{
+		cel.Variable("jwks", JwksType),
 		// register native types
-		ext.NativeTypes(reflect.TypeFor[Set]()),
+		ext.NativeTypes(reflect.TypeFor[Jwks](), reflect.TypeFor[Set]()),
 		// extend environment with function overloads
 		c.extendEnv,
 	}
 }
 
 func (*lib) ProgramOptions() []cel.ProgramOption {
-	return []cel.ProgramOption{}
+	return []cel.ProgramOption{
+		cel.Globals(
+			map[string]any{
+				"jwks": Jwks{},
+			},
+		),
+	}
 }
 
 func (*lib) extendEnv(env *cel.Env) (*cel.Env, error) {
@@ -39,8 +46,8 @@ func (*lib) extendEnv(env *cel.Env) (*cel.Env, error) {
 	impl := impl{adapter}
 	// build our function overloads
 	libraryDecls := map[string][]cel.FunctionOpt{
-		"jwks.Fetch": {
-			cel.Overload("fetch_string", []*cel.Type{types.StringType}, SetType, cel.UnaryBinding(impl.fetch)),
+		"Fetch": {
+			cel.MemberOverload("fetch_string", []*cel.Type{JwksType, types.StringType}, SetType, cel.BinaryBinding(impl.fetch)),
 		},
 	}
 	// create env options corresponding to our function overloads
@@ -2,10 +2,19 @@ package jwk
 
 import (
 	"github.com/google/cel-go/common/types"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
 	"github.com/lestrrat-go/jwx/v3/jwk"
 )
 
-var SetType = types.NewOpaqueType("jwk.Set")
+var (
+	JwksType = types.NewOpaqueType("jwk.Jwks")
+	SetType  = types.NewOpaqueType("jwk.Set")
+)
+
+// Jwks is the receiver of the jwks functions, fetched key sets are memoized for the duration of a request when Memo is set
+type Jwks struct {
+	Memo *memo.Memo
+}
 
 type Set struct {
 	jwk.Set
@@ -17,6 +17,31 @@ type impl struct {
 	types.Adapter
 }
 
+func (c *impl) jwt_decode_string_string(args ...ref.Val) ref.Val {
+	if jwt, err := utils.ConvertToNative[Jwt](args[0]); err != nil {
+		return types.WrapErr(err)
+	} else if token, err := utils.ConvertToNative[string](args[1]); err != nil {
+		return types.WrapErr(err)
+	} else if key, err := utils.ConvertToNative[string](args[2]); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return jwt.Memo.Call("jwt.Decode", func() ref.Val { return c.decode_string_string(args[1], args[2]) }, token, key)
+	}
+}
+
+func (c *impl) jwt_decode_string_set(args ...ref.Val) ref.Val {
+	if jwt, err := utils.ConvertToNative[Jwt](args[0]); err != nil {
+		return types.WrapErr(err)
+	} else if token, err := utils.ConvertToNative[string](args[1]); err != nil {
+		return types.WrapErr(err)
+	} else if set, err := utils.ConvertToNative[jwklib.Set](args[2]); err != nil {
+		return types.WrapErr(err)
+	} else {
+		// key sets are compared by identity, sets fetched with jwks.Fetch in the same request are the same
+		return jwt.Memo.Call("jwt.Decode", func() ref.Val { return c.decode_string_set(args[1], args[2]) }, token, set.Set)
+	}
+}
+
 func (c *impl) decode_string_string(token ref.Val, key ref.Val) ref.Val {
 	if token, err := utils.ConvertToNative[string](token); err != nil {
 		return types.WrapErr(err)
@@ -24,15 +24,22 @@ func (c *lib) CompileOptions() []cel.EnvOption {
 	return []cel.EnvOption{
 		// register jwk lib
 		jwk.Lib(),
-		// register token type
-		ext.NativeTypes(reflect.TypeFor[Token]()),
+		cel.Variable("jwt", JwtType),
+		// register native types
+		ext.NativeTypes(reflect.TypeFor[Jwt](), reflect.TypeFor[Token]()),
 		// extend environment with function overloads
 		c.extendEnv,
 	}
 }
 
 func (*lib) ProgramOptions() []cel.ProgramOption {
-	return []cel.ProgramOption{}
+	return []cel.ProgramOption{
+		cel.Globals(
+			map[string]any{
+				"jwt": Jwt{},
+			},
+		),
+	}
 }
 
 func (*lib) extendEnv(env *cel.Env) (*cel.Env, error) {
@@ -42,9 +49,9 @@ func (*lib) extendEnv(env *cel.Env) (*cel.Env, error) {
 	impl := impl{adapter}
 	// build our function overloads
 	libraryDecls := map[string][]cel.FunctionOpt{
-		"jwt.Decode": {
-			cel.Overload("decode_string_string", []*cel.Type{types.StringType, types.StringType}, TokenType, cel.BinaryBinding(impl.decode_string_string)),
-			cel.Overload("decode_string_set", []*cel.Type{types.StringType, jwk.SetType}, TokenType, cel.BinaryBinding(impl.decode_string_set)),
+		"Decode": {
+			cel.MemberOverload("decode_string_string", []*cel.Type{JwtType, types.StringType, types.StringType}, TokenType, cel.FunctionBinding(impl.jwt_decode_string_string)),
+			cel.MemberOverload("decode_string_set", []*cel.Type{JwtType, types.StringType, jwk.SetType}, TokenType, cel.FunctionBinding(impl.jwt_decode_string_set)),
 		},
 	}
 	// create env options corresponding to our function overloads
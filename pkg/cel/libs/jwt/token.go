@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"github.com/google/cel-go/common/types"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -11,3 +12,10 @@ type Token struct {
 	Claims *structpb.Struct
 	Valid  bool
 }
+
+var JwtType = types.NewOpaqueType("jwt.Jwt")
+
+// Jwt is the receiver of the jwt functions, decoded tokens are memoized for the duration of a request when Memo is set
+type Jwt struct {
+	Memo *memo.Memo
+}
@@ -1,9 +1,12 @@
 package mcp
 
 import (
+	"encoding/json"
+
 	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
 	"github.com/kyverno/kyverno/pkg/cel/utils"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type impl struct {
@@ -29,6 +32,89 @@ func (c *impl) mcp_parse(mcp, value ref.Val) ref.Val {
 	return c.NativeToValue(mcpRequest)
 }
 
+func (c *impl) mcp_parse_batch(mcp, value ref.Val) ref.Val {
+	mcpImpl, err := utils.ConvertToNative[MCP](mcp)
+	if err != nil {
+		return types.WrapErr(err)
+	}
+
+	stringBody, err := utils.ConvertToNative[string](value)
+	if err != nil {
+		return types.WrapErr(err)
+	}
+
+	mcpRequests, err := mcpImpl.ParseBatch([]byte(stringBody))
+	if err != nil {
+		return types.WrapErr(err)
+	}
+
+	return c.NativeToValue(mcpRequests)
+}
+
+func (c *impl) mcp_tool_allowed(args ...ref.Val) ref.Val {
+	mcpRequest, err := utils.ConvertToNative[*MCPRequest](args[1])
+	if err != nil {
+		return types.WrapErr(err)
+	}
+
+	patterns, err := utils.ConvertToNative[[]string](args[2])
+	if err != nil {
+		return types.WrapErr(err)
+	}
+
+	return types.Bool(mcpRequest.ToolAllowed(patterns))
+}
+
+func (c *impl) mcp_tools_allowed(args ...ref.Val) ref.Val {
+	mcpRequests, err := utils.ConvertToNative[[]*MCPRequest](args[1])
+	if err != nil {
+		return types.WrapErr(err)
+	}
+
+	patterns, err := utils.ConvertToNative[[]string](args[2])
+	if err != nil {
+		return types.WrapErr(err)
+	}
+
+	// every tool call in the batch must be allowed, other messages are ignored
+	for _, mcpRequest := range mcpRequests {
+		if mcpRequest.ToolCall != nil && !mcpRequest.ToolAllowed(patterns) {
+			return types.False
+		}
+	}
+
+	return types.True
+}
+
+func (c *impl) mcp_validate_arguments(request, schema ref.Val) ref.Val {
+	mcpRequest, err := utils.ConvertToNative[*MCPRequest](request)
+	if err != nil {
+		return types.WrapErr(err)
+	}
+
+	var schemaMap map[string]any
+	// the schema can be given as a JSON string or as a map
+	if stringSchema, ok := schema.(types.String); ok {
+		if err := json.Unmarshal([]byte(stringSchema), &schemaMap); err != nil {
+			return types.WrapErr(err)
+		}
+	} else {
+		// go through structpb to get plain go values for nested maps and lists
+		schemaStruct, err := utils.ConvertToNative[*structpb.Struct](schema)
+		if err != nil {
+			return types.WrapErr(err)
+		}
+		schemaMap = schemaStruct.AsMap()
+	}
+
+	violations, err := mcpRequest.ValidateArguments(schemaMap)
+	if err != nil {
+		return types.WrapErr(err)
+	}
+
+	return c.NativeToValue(violations)
+}
+
 func (c *impl) mcp_get_string(args ...ref.Val) ref.Val {
 	mcpRequest, err := utils.ConvertToNative[*MCPRequest](args[0])
 	if err != nil {
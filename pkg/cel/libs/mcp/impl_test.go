@@ -16,13 +16,18 @@ import (
 
 // mockMCPImpl is a mock implementation of MCPImpl for tests
 type mockMCPImpl struct {
-	parseFn func([]byte) (*MCPRequest, error)
+	parseFn      func([]byte) (*MCPRequest, error)
+	parseBatchFn func([]byte) ([]*MCPRequest, error)
 }
 
 func (m *mockMCPImpl) Parse(b []byte) (*MCPRequest, error) {
 	return m.parseFn(b)
 }
 
+func (m *mockMCPImpl) ParseBatch(b []byte) ([]*MCPRequest, error) {
+	return m.parseBatchFn(b)
+}
+
 // setupTestEnv creates a CEL environment with MCPRequest type registered and returns an impl instance
 func setupTestEnv(t *testing.T) *impl {
 	env, err := cel.NewEnv(
@@ -855,3 +860,117 @@ func TestImpl_mcp_get_bool_slice(t *testing.T) {
 		})
 	}
 }
+
+func TestImpl_mcp_parse_batch(t *testing.T) {
+	tests := []struct {
+		name        string
+		mcpImpl     MCPImpl
+		value       any
+		want        []*MCPRequest
+		expectError bool
+	}{
+		{
+			name: "successful parse",
+			mcpImpl: &mockMCPImpl{
+				parseBatchFn: func(b []byte) ([]*MCPRequest, error) {
+					return []*MCPRequest{{Method: "a"}, {Method: "b"}}, nil
+				},
+			},
+			value: `[]`,
+			want:  []*MCPRequest{{Method: "a"}, {Method: "b"}},
+		},
+		{
+			name: "ParseBatch returns error",
+			mcpImpl: &mockMCPImpl{
+				parseBatchFn: func(b []byte) ([]*MCPRequest, error) {
+					return nil, errors.New("parse err")
+				},
+			},
+			value:       `anything`,
+			expectError: true,
+		},
+		{
+			name:        "cannot convert value to string",
+			mcpImpl:     &mockMCPImpl{},
+			value:       1234,
+			expectError: true,
+		},
+	}
+
+	impl := setupTestEnvWithMCP(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := impl.mcp_parse_batch(impl.NativeToValue(MCP{tt.mcpImpl}), impl.NativeToValue(tt.value))
+			if tt.expectError {
+				if got.Type() != types.ErrType {
+					t.Errorf("Expected error, got %v (type %v)", got, got.Type())
+				}
+				return
+			}
+			native, err := got.ConvertToNative(reflect.TypeFor[[]*MCPRequest]())
+			if err != nil {
+				t.Fatalf("ConvertToNative err: %v", err)
+			}
+			if !reflect.DeepEqual(native, tt.want) {
+				t.Errorf("got %v, want %v", native, tt.want)
+			}
+		})
+	}
+}
+
+func TestImpl_mcp_tool_allowed(t *testing.T) {
+	impl := setupTestEnvWithMCP(t)
+	allowList := impl.NativeToValue([]string{"fs_*", "search"})
+	mcpVal := impl.NativeToValue(MCP{&mockMCPImpl{}})
+
+	tests := []struct {
+		name    string
+		request *MCPRequest
+		want    ref.Val
+	}{
+		{
+			name:    "exact match",
+			request: &MCPRequest{ToolCall: &mcp.CallToolParams{Name: "search"}},
+			want:    types.True,
+		},
+		{
+			name:    "glob match",
+			request: &MCPRequest{ToolCall: &mcp.CallToolParams{Name: "fs_read"}},
+			want:    types.True,
+		},
+		{
+			name:    "no match",
+			request: &MCPRequest{ToolCall: &mcp.CallToolParams{Name: "shell"}},
+			want:    types.False,
+		},
+		{
+			name:    "not a tool call",
+			request: &MCPRequest{Method: string(mcp.MethodToolsList)},
+			want:    types.False,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := impl.mcp_tool_allowed(mcpVal, impl.NativeToValue(tt.request), allowList)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		allowed := []*MCPRequest{
+			{Method: string(mcp.MethodToolsList)},
+			{ToolCall: &mcp.CallToolParams{Name: "fs_write"}},
+		}
+		if got := impl.mcp_tools_allowed(mcpVal, impl.NativeToValue(allowed), allowList); got != types.True {
+			t.Errorf("got %v, want true", got)
+		}
+		denied := append(allowed, &MCPRequest{ToolCall: &mcp.CallToolParams{Name: "shell"}})
+		if got := impl.mcp_tools_allowed(mcpVal, impl.NativeToValue(denied), allowList); got != types.False {
+			t.Errorf("got %v, want false", got)
+		}
+	})
+}
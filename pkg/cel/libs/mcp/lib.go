@@ -65,6 +65,16 @@ func (l *lib) extendEnv(env *cel.Env) (*cel.Env, error) {
 		"ToolsCallMethod":              string(mcp.MethodToolsCall),
 		"SetLogLevelMethod":            string(mcp.MethodSetLogLevel),
 		"ElicitationCreateMethod":      string(mcp.MethodElicitationCreate),
+		// notifications
+		"InitializedNotification":          "notifications/initialized",
+		"CancelledNotification":            "notifications/cancelled",
+		"ProgressNotification":             "notifications/progress",
+		"MessageNotification":              "notifications/message",
+		"RootsListChangedNotification":     "notifications/roots/list_changed",
+		"ResourcesListChangedNotification": mcp.MethodNotificationResourcesListChanged,
+		"ResourceUpdatedNotification":      mcp.MethodNotificationResourceUpdated,
+		"PromptsListChangedNotification":   mcp.MethodNotificationPromptsListChanged,
+		"ToolsListChangedNotification":     mcp.MethodNotificationToolsListChanged,
 	}
 
 	libraryDecls := map[string][]cel.FunctionOpt{
@@ -75,6 +85,32 @@ func (l *lib) extendEnv(env *cel.Env) (*cel.Env, error) {
 				cel.BinaryBinding(impl.mcp_parse),
 			),
 		},
+		"ParseBatch": {
+			cel.MemberOverload("mcp_parse_batch_bytes_dyn",
+				[]*cel.Type{MCPType, types.DynType},
+				cel.ListType(MCPRequestType),
+				cel.BinaryBinding(impl.mcp_parse_batch),
+			),
+		},
+		"ToolAllowed": {
+			cel.MemberOverload("mcp_tool_allowed_request_list",
+				[]*cel.Type{MCPType, MCPRequestType, cel.ListType(types.StringType)},
+				types.BoolType,
+				cel.FunctionBinding(impl.mcp_tool_allowed),
+			),
+			cel.MemberOverload("mcp_tool_allowed_requests_list",
+				[]*cel.Type{MCPType, cel.ListType(MCPRequestType), cel.ListType(types.StringType)},
+				types.BoolType,
+				cel.FunctionBinding(impl.mcp_tools_allowed),
+			),
+		},
+		"ValidateArguments": {
+			cel.MemberOverload("mcp_validate_arguments_dyn",
+				[]*cel.Type{MCPRequestType, types.DynType},
+				cel.ListType(types.StringType),
+				cel.BinaryBinding(impl.mcp_validate_arguments),
+			),
+		},
 		"GetStringArgument": {
 			cel.MemberOverload("mcp_get_string",
 				[]*cel.Type{MCPRequestType, types.StringType, types.StringType},
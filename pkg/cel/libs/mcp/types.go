@@ -1,10 +1,15 @@
 package mcp
 
 import (
+	"encoding/json"
 	"strconv"
 
 	"github.com/google/cel-go/common/types"
+	"github.com/kyverno/pkg/ext/wildcard"
 	"github.com/mark3labs/mcp-go/mcp"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
 )
 
 var (
@@ -14,6 +19,7 @@ var (
 
 type MCPImpl interface {
 	Parse([]byte) (*MCPRequest, error)
+	ParseBatch([]byte) ([]*MCPRequest, error)
 }
 
 type MCP struct {
@@ -24,6 +30,15 @@ type MCPRequest struct {
 	Method string
 	ID     mcp.RequestId
 
+	// IsNotification is true when the message is a JSON-RPC notification (no id)
+	IsNotification bool
+	// IsResponse is true when the message is a JSON-RPC response (result or error, no method)
+	IsResponse bool
+
+	// Response
+	Result any
+	Error  *mcp.JSONRPCErrorDetails
+
 	Paginated *mcp.PaginatedParams // For all list methods
 
 	// Tools
@@ -91,6 +106,43 @@ func (r *MCPRequest) GetRawArguments() any {
 	return nil
 }
 
+// ToolAllowed returns true if the request is a tool call and the tool name matches
+// at least one of the given glob patterns (e.g. "fs_*", "search")
+func (r *MCPRequest) ToolAllowed(patterns []string) bool {
+	if r.ToolCall == nil {
+		return false
+	}
+	for _, pattern := range patterns {
+		if wildcard.Match(pattern, r.ToolCall.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateArguments validates the request arguments against an OpenAPI v3 schema
+// and returns the list of violations, an empty list means the arguments are valid
+func (r *MCPRequest) ValidateArguments(schema map[string]any) ([]string, error) {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var s spec.Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	args := r.GetArguments()
+	if args == nil {
+		args = map[string]any{}
+	}
+	result := validate.NewSchemaValidator(&s, nil, "", strfmt.Default).Validate(args)
+	violations := make([]string, 0, len(result.Errors))
+	for _, err := range result.Errors {
+		violations = append(violations, err.Error())
+	}
+	return violations, nil
+}
+
 // GetString returns a string argument by key, or the default value if not found
 func (r *MCPRequest) GetStringArgument(key string, defaultValue string) string {
 	args := r.GetArguments()
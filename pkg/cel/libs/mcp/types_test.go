@@ -617,3 +617,63 @@ func TestMCPRequest_GetBoolSliceArgument(t *testing.T) {
 		})
 	}
 }
+
+func TestMCPRequest_ValidateArguments(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"path"},
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":    "string",
+				"pattern": "^/data/",
+			},
+			"limit": map[string]any{
+				"type":    "integer",
+				"maximum": 100,
+			},
+		},
+		"additionalProperties": false,
+	}
+	tests := []struct {
+		name    string
+		request *MCPRequest
+		valid   bool
+	}{
+		{
+			name:    "valid arguments",
+			request: requestWithToolCall(map[string]any{"path": "/data/file", "limit": 10}),
+			valid:   true,
+		},
+		{
+			name:    "missing required argument",
+			request: requestWithToolCall(map[string]any{"limit": 10}),
+		},
+		{
+			name:    "pattern mismatch",
+			request: requestWithToolCall(map[string]any{"path": "/etc/passwd"}),
+		},
+		{
+			name:    "maximum exceeded",
+			request: requestWithToolCall(map[string]any{"path": "/data/file", "limit": 1000}),
+		},
+		{
+			name:    "additional property",
+			request: requestWithToolCall(map[string]any{"path": "/data/file", "force": true}),
+		},
+		{
+			name:    "no arguments",
+			request: &MCPRequest{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := tt.request.ValidateArguments(schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := len(violations) == 0; got != tt.valid {
+				t.Errorf("valid = %v, want %v (violations: %v)", got, tt.valid, violations)
+			}
+		})
+	}
+}
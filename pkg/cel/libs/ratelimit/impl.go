@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
+)
+
+type impl struct {
+	types.Adapter
+}
+
+// bucket identifies the arguments of an Allow call
+type bucket struct {
+	key    string
+	limit  int64
+	period time.Duration
+	burst  int64
+}
+
+func (c *impl) allow_string_int_int(args ...ref.Val) ref.Val {
+	// rate is expressed in requests per second
+	return c.allow(args[0], args[1], args[2], types.Duration{Duration: time.Second}, args[3])
+}
+
+func (c *impl) allow_string_int_duration_int(args ...ref.Val) ref.Val {
+	return c.allow(args[0], args[1], args[2], args[3], args[4])
+}
+
+func (c *impl) allow(ratelimit, key, limit, period, burst ref.Val) ref.Val {
+	if ratelimit, err := utils.ConvertToNative[RateLimit](ratelimit); err != nil {
+		return types.WrapErr(err)
+	} else if key, err := utils.ConvertToNative[string](key); err != nil {
+		return types.WrapErr(err)
+	} else if limit, err := utils.ConvertToNative[int64](limit); err != nil {
+		return types.WrapErr(err)
+	} else if period, err := utils.ConvertToNative[time.Duration](period); err != nil {
+		return types.WrapErr(err)
+	} else if burst, err := utils.ConvertToNative[int64](burst); err != nil {
+		return types.WrapErr(err)
+	} else {
+		return ratelimit.Memo.Call("ratelimit.Allow", func() ref.Val {
+			return c.NativeToValue(ratelimit.Allow(key, limit, period, burst))
+		}, bucket{key, limit, period, burst})
+	}
+}
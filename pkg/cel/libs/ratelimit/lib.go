@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+)
+
+type lib struct {
+	ratelimit RateLimit
+}
+
+func Lib(ratelimit RateLimitImpl) cel.EnvOption {
+	// create the cel lib env option
+	return cel.Lib(&lib{
+		ratelimit: RateLimit{RateLimitImpl: ratelimit},
+	})
+}
+
+func (*lib) LibraryName() string {
+	return "kyverno.ratelimit"
+}
+
+func (l *lib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Variable("ratelimit", RateLimitType),
+		// register native types
+		ext.NativeTypes(
+			reflect.TypeFor[RateLimit](),
+			reflect.TypeFor[Decision](),
+			ext.ParseStructTags(true),
+		),
+		// extend environment with function overloads
+		l.extendEnv,
+	}
+}
+
+func (l *lib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{
+		cel.Globals(
+			map[string]any{
+				"ratelimit": l.ratelimit,
+			},
+		),
+	}
+}
+
+func (*lib) extendEnv(env *cel.Env) (*cel.Env, error) {
+	// get env type adapter
+	adapter := env.CELTypeAdapter()
+	// create implementation with adapter
+	impl := impl{adapter}
+	// build our function overloads
+	libraryDecls := map[string][]cel.FunctionOpt{
+		"Allow": {
+			cel.MemberOverload("ratelimit_allow_string_int_int", []*cel.Type{RateLimitType, types.StringType, types.IntType, types.IntType}, DecisionType, cel.FunctionBinding(impl.allow_string_int_int)),
+			cel.MemberOverload("ratelimit_allow_string_int_duration_int", []*cel.Type{RateLimitType, types.StringType, types.IntType, types.DurationType, types.IntType}, DecisionType, cel.FunctionBinding(impl.allow_string_int_duration_int)),
+		},
+	}
+	// create env options corresponding to our function overloads
+	options := []cel.EnvOption{}
+	for name, overloads := range libraryDecls {
+		options = append(options, cel.Function(name, overloads...))
+	}
+	// extend environment with our function overloads
+	return env.Extend(options...)
+}
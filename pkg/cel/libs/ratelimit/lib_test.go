@@ -0,0 +1,92 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/ratelimit"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
+	"github.com/stretchr/testify/assert"
+)
+
+type call struct {
+	key    string
+	limit  int64
+	period time.Duration
+	burst  int64
+}
+
+type mockRateLimit struct {
+	calls    []call
+	decision ratelimit.Decision
+}
+
+func (m *mockRateLimit) Allow(key string, limit int64, period time.Duration, burst int64) ratelimit.Decision {
+	m.calls = append(m.calls, call{key, limit, period, burst})
+	return m.decision
+}
+
+func TestAllow(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		decision ratelimit.Decision
+		want     any
+		wantCall call
+	}{{
+		name:     "rate and burst",
+		source:   `ratelimit.Allow("tenant-a", 10, 20).allowed`,
+		decision: ratelimit.Decision{Allowed: true},
+		want:     true,
+		wantCall: call{"tenant-a", 10, time.Second, 20},
+	}, {
+		name:     "limit per period",
+		source:   `ratelimit.Allow("tenant-a", 100, duration("1m"), 10).retryAfter`,
+		decision: ratelimit.Decision{RetryAfter: 6},
+		want:     int64(6),
+		wantCall: call{"tenant-a", 100, time.Minute, 10},
+	}, {
+		name:     "fields",
+		source:   `ratelimit.Allow("tenant-a", 1, 5).remaining == 4 && ratelimit.Allow("tenant-a", 1, 5).limit == 5`,
+		decision: ratelimit.Decision{Allowed: true, Limit: 5, Remaining: 4},
+		want:     true,
+		wantCall: call{"tenant-a", 1, time.Second, 5},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockRateLimit{decision: tt.decision}
+			env, err := cel.NewEnv(ratelimit.Lib(mock))
+			assert.NoError(t, err)
+			ast, issues := env.Compile(tt.source)
+			assert.Nil(t, issues)
+			prog, err := env.Program(ast)
+			assert.NoError(t, err)
+			out, _, err := prog.Eval(map[string]any{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.Value())
+			assert.Equal(t, tt.wantCall, mock.calls[0])
+		})
+	}
+}
+
+func TestAllowMemo(t *testing.T) {
+	mock := &mockRateLimit{decision: ratelimit.Decision{Allowed: true, Limit: 5, Remaining: 4}}
+	env, err := cel.NewEnv(ratelimit.Lib(mock))
+	assert.NoError(t, err)
+	ast, issues := env.Compile(`ratelimit.Allow("tenant-a", 1, 5).remaining == 4 && ratelimit.Allow("tenant-a", 1, 5).limit == 5 && ratelimit.Allow("tenant-b", 1, 5).allowed`)
+	assert.Nil(t, issues)
+	prog, err := env.Program(ast)
+	assert.NoError(t, err)
+	m := memo.New()
+	activation := map[string]any{
+		"ratelimit": ratelimit.RateLimit{RateLimitImpl: mock, Memo: m},
+	}
+	out, _, err := prog.Eval(activation)
+	assert.NoError(t, err)
+	assert.Equal(t, true, out.Value())
+	// evaluating again for the same request doesn't consume more tokens
+	_, _, err = prog.Eval(activation)
+	assert.NoError(t, err)
+	assert.Equal(t, []call{{"tenant-a", 1, time.Second, 5}, {"tenant-b", 1, time.Second, 5}}, mock.calls)
+}
@@ -0,0 +1,31 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
+)
+
+var (
+	RateLimitType = types.NewOpaqueType("ratelimit.RateLimit")
+	DecisionType  = types.NewObjectType("ratelimit.Decision")
+)
+
+type RateLimitImpl interface {
+	Allow(key string, limit int64, period time.Duration, burst int64) Decision
+}
+
+type RateLimit struct {
+	RateLimitImpl
+	// Memo makes sure a request consumes a single token per bucket, even when Allow is called several times
+	Memo *memo.Memo
+}
+
+type Decision struct {
+	Allowed    bool  `cel:"allowed"`
+	Limit      int64 `cel:"limit"`
+	Remaining  int64 `cel:"remaining"`
+	RetryAfter int64 `cel:"retryAfter"`
+	Reset      int64 `cel:"reset"`
+}
@@ -0,0 +1,70 @@
+package memo
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// Memo memoizes the results of library calls (decoding a token, fetching a key set, parsing a json document, consuming
+// a rate limit token) for the duration of a request, the same call made by several policies evaluated for the same
+// request runs only once.
+// A nil Memo doesn't memoize anything.
+type Memo struct {
+	lock    sync.Mutex
+	entries map[key]ref.Val
+}
+
+type key struct {
+	function string
+	args     [2]any
+}
+
+func New() *Memo {
+	return &Memo{
+		entries: map[key]ref.Val{},
+	}
+}
+
+// Call returns the memoized result of a function call, calling fn the first time the function is called with the given args.
+// Args must be comparable, calls with non comparable or more than two args are not memoized.
+func (m *Memo) Call(function string, fn func() ref.Val, args ...any) ref.Val {
+	if m == nil || len(args) > 2 {
+		return fn()
+	}
+	k := key{function: function}
+	for i, arg := range args {
+		if arg != nil && !reflect.TypeOf(arg).Comparable() {
+			return fn()
+		}
+		k.args[i] = arg
+	}
+	m.lock.Lock()
+	out, ok := m.entries[k]
+	m.lock.Unlock()
+	if ok {
+		return out
+	}
+	out = fn()
+	m.lock.Lock()
+	m.entries[k] = out
+	m.lock.Unlock()
+	return out
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying a new Memo, policies evaluated with this context share memoized results.
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, New())
+}
+
+// FromContext returns the Memo carried by the context, if any.
+func FromContext(ctx context.Context) *Memo {
+	if m, ok := ctx.Value(contextKey{}).(*Memo); ok {
+		return m
+	}
+	return nil
+}
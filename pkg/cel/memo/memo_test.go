@@ -0,0 +1,47 @@
+package memo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemo(t *testing.T) {
+	calls := 0
+	fn := func() ref.Val {
+		calls++
+		return types.Int(calls)
+	}
+	m := New()
+	assert.Equal(t, types.Int(1), m.Call("f", fn, "a"))
+	assert.Equal(t, types.Int(1), m.Call("f", fn, "a"))
+	assert.Equal(t, types.Int(2), m.Call("f", fn, "b"))
+	assert.Equal(t, types.Int(3), m.Call("g", fn, "a"))
+	assert.Equal(t, types.Int(4), m.Call("f", fn, "a", "b"))
+	assert.Equal(t, types.Int(4), m.Call("f", fn, "a", "b"))
+	// non comparable args are not memoized
+	assert.Equal(t, types.Int(5), m.Call("f", fn, []string{"a"}))
+	assert.Equal(t, types.Int(6), m.Call("f", fn, []string{"a"}))
+}
+
+func TestNilMemo(t *testing.T) {
+	calls := 0
+	fn := func() ref.Val {
+		calls++
+		return types.Int(calls)
+	}
+	var m *Memo
+	assert.Equal(t, types.Int(1), m.Call("f", fn, "a"))
+	assert.Equal(t, types.Int(2), m.Call("f", fn, "a"))
+}
+
+func TestContext(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+	ctx := NewContext(context.Background())
+	m := FromContext(ctx)
+	assert.NotNil(t, m)
+	assert.Same(t, m, FromContext(ctx))
+}
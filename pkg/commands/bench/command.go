@@ -0,0 +1,153 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/envoy"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/bench"
+	httpcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	vpolcompiler "github.com/kyverno/kyverno-envoy-plugin/pkg/engine/compiler"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/sources"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/dynamic"
+)
+
+func Command() *cobra.Command {
+	var mode string
+	var policiesPath string
+	var requestsPath string
+	var config bench.Config
+	var output string
+	command := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark policies against a corpus of requests",
+		Long: `Benchmark policies against a corpus of requests.
+
+Requests are evaluated by the same engine as the authz servers, by every policy and by every rule.
+Throughput, p50/p99 latencies and allocations are reported for each of them.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if config.Iterations < 1 {
+				return fmt.Errorf("iterations must be at least 1")
+			}
+			policies, err := loadPolicies(ctx, policiesPath, vpol.EvaluationMode(mode))
+			if err != nil {
+				return err
+			}
+			documents, err := loadDocuments(requestsPath)
+			if err != nil {
+				return err
+			}
+			var report *bench.Report
+			switch vpol.EvaluationMode(mode) {
+			case v1alpha1.EvaluationModeEnvoy:
+				requests, err := bench.EnvoyRequests(documents)
+				if err != nil {
+					return err
+				}
+				report, err = bench.Run(
+					ctx,
+					config,
+					vpolcompiler.NewCompiler[dynamic.Interface, *authv3.CheckRequest, *authv3.CheckResponse](),
+					func(source core.Source[engine.EnvoyPolicy]) (envoy.Engine, error) {
+						return envoy.NewEngine(envoy.Config{}, source)
+					},
+					policies,
+					requests,
+				)
+				if err != nil {
+					return err
+				}
+			case v1alpha1.EvaluationModeHTTP:
+				requests, err := bench.HTTPRequests(documents)
+				if err != nil {
+					return err
+				}
+				report, err = bench.Run(
+					ctx,
+					config,
+					vpolcompiler.NewCompiler[dynamic.Interface, *httpcel.CheckRequest, *httpcel.CheckResponse](),
+					func(source core.Source[engine.HTTPPolicy]) (http.Engine, error) {
+						return http.NewEngine(http.Config{}, source)
+					},
+					policies,
+					requests,
+				)
+				if err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unsupported evaluation mode: %s (supported modes are %s and %s)", mode, v1alpha1.EvaluationModeEnvoy, v1alpha1.EvaluationModeHTTP)
+			}
+			switch output {
+			case "json":
+				return report.WriteJSON(cmd.OutOrStdout())
+			case "table":
+				return report.WriteTable(cmd.OutOrStdout())
+			default:
+				return fmt.Errorf("unsupported output format: %s", output)
+			}
+		},
+	}
+	command.Flags().StringVar(&mode, "mode", string(v1alpha1.EvaluationModeEnvoy), "Evaluation mode of the benchmarked policies (Envoy or HTTP), policies with another evaluation mode are ignored")
+	command.Flags().StringVar(&policiesPath, "policies", "", "Directory containing the policies (and function libraries)")
+	command.Flags().StringVar(&requestsPath, "requests", "", "File or directory containing the requests (yaml or json)")
+	command.Flags().IntVar(&config.Iterations, "iterations", 1000, "Number of times every request is evaluated")
+	command.Flags().IntVar(&config.Warmup, "warmup", 10, "Number of iterations run before measuring")
+	command.Flags().StringVar(&output, "output", "table", "Output format (table or json)")
+	_ = command.MarkFlagRequired("policies")
+	_ = command.MarkFlagRequired("requests")
+	return command
+}
+
+// loadPolicies loads the policies of the given evaluation mode, function libraries are registered first like in the servers.
+func loadPolicies(ctx context.Context, path string, mode vpol.EvaluationMode) ([]*vpol.ValidatingPolicy, error) {
+	fsys := os.DirFS(path)
+	libraries, err := sources.NewFsFunctionLibraries(fsys).Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, library := range libraries {
+		functions.Default().Store(path+"#"+library.Name, library.Spec.Functions...)
+	}
+	policies, err := sources.NewFsPolicies(fsys).Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []*vpol.ValidatingPolicy
+	for _, policy := range policies {
+		if policy.Spec.EvaluationMode() == mode {
+			out = append(out, policy)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no %s policy found in %s", mode, path)
+	}
+	return out, nil
+}
+
+func loadDocuments(path string) ([][]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return bench.LoadDocuments(os.DirFS(path), ".")
+	}
+	return bench.LoadDocuments(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
@@ -1,6 +1,7 @@
 package root
 
 import (
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/bench"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/run"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/version"
@@ -17,6 +18,7 @@ func Command() *cobra.Command {
 		},
 	}
 	root.AddCommand(
+		bench.Command(),
 		run.Command(),
 		serve.Command(),
 		version.Command(),
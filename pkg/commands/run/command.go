@@ -29,6 +29,8 @@ func Command() *cobra.Command {
 	var certFile string
 	var keyFile string
 	var nestedRequest bool
+	var strict bool
+	var grpcReflection bool
 	command := &cobra.Command{
 		Use:   "run",
 		Short: "Run authz-server controller",
@@ -46,11 +48,12 @@ func Command() *cobra.Command {
 					return fmt.Errorf("failed to construct manager: %w", err)
 				}
 				// register controller
-				if err := setup(mgr); err != nil {
+				reconciler, err := setup(mgr, strict, grpcReflection)
+				if err != nil {
 					return fmt.Errorf("failed to setup controller: %w", err)
 				}
 				// run
-				return run(ctx, mgr, probesAddress)
+				return run(ctx, mgr, probesAddress, reconciler.ready)
 			})
 		},
 	}
@@ -61,6 +64,8 @@ func Command() *cobra.Command {
 	command.Flags().StringVar(&certFile, "cert-file", "", "File containing tls certificate")
 	command.Flags().StringVar(&keyFile, "key-file", "", "File containing tls private key")
 	command.Flags().BoolVar(&nestedRequest, "nested-request", false, "Expect the requests to validate to be in the body of the original request")
+	command.Flags().BoolVar(&strict, "strict", false, "Report the servers as not ready when policies fail to load or compile")
+	command.Flags().BoolVar(&grpcReflection, "grpc-reflection", true, "Enable the gRPC reflection service of the envoy servers")
 	clientcmd.BindOverrideFlags(&kubeConfigOverrides, command.Flags(), clientcmd.RecommendedConfigOverrideFlags("kube-"))
 	return command
 }
@@ -88,19 +93,24 @@ func createManager(config *rest.Config, metricsAddress string, leaderElection bo
 	})
 }
 
-func setup(mgr manager.Manager) error {
+func setup(mgr manager.Manager, strict, reflection bool) (*reconciler, error) {
 	reconciler := &reconciler{
-		client:  mgr.GetClient(),
-		servers: map[reconcile.Request]*entry{},
-		lock:    &sync.Mutex{},
+		client:     mgr.GetClient(),
+		servers:    map[reconcile.Request]*entry{},
+		strict:     strict,
+		reflection: reflection,
+		lock:       &sync.Mutex{},
 	}
-	return ctrl.
+	if err := ctrl.
 		NewControllerManagedBy(mgr).
 		For(&v1alpha1.AuthorizationServer{}).
-		Complete(reconciler)
+		Complete(reconciler); err != nil {
+		return nil, err
+	}
+	return reconciler, nil
 }
 
-func run(ctx context.Context, mgr manager.Manager, probesAddress string) error {
+func run(ctx context.Context, mgr manager.Manager, probesAddress string, ready func() bool) error {
 	// track errors
 	var probesErr, mgrErr error
 	// create a cancellable context
@@ -123,7 +133,7 @@ func run(ctx context.Context, mgr manager.Manager, probesAddress string) error {
 		return fmt.Errorf("failed to wait for cache sync")
 	}
 	// create http and grpc servers
-	probesServer := probes.NewServer(probesAddress)
+	probesServer := probes.NewServer(probesAddress, ready)
 	// run servers
 	group.StartWithContext(ctx, func(ctx context.Context) {
 		// probes
@@ -10,12 +10,14 @@ import (
 	"github.com/hairyhenderson/go-fsimpl/filefs"
 	"github.com/hairyhenderson/go-fsimpl/gitfs"
 	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/envoy"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/http"
 	httplib "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
 	vpolcompiler "github.com/kyverno/kyverno-envoy-plugin/pkg/engine/compiler"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/sources"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/probes"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/utils/ocifs"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
 	sdksources "github.com/kyverno/kyverno-envoy-plugin/sdk/core/sources"
@@ -42,14 +44,29 @@ var (
 
 type entry struct {
 	cancel func() error
+	ready  func() bool
 }
 
 type reconciler struct {
-	client   client.Client
-	servers  map[reconcile.Request]*entry
-	certFile string
-	keyFile  string
-	lock     *sync.Mutex
+	client     client.Client
+	servers    map[reconcile.Request]*entry
+	certFile   string
+	keyFile    string
+	strict     bool
+	reflection bool
+	lock       *sync.Mutex
+}
+
+// ready returns true when the policies of all the running servers are ready.
+func (r *reconciler) ready() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, server := range r.servers {
+		if !server.ready() {
+			return false
+		}
+	}
+	return true
 }
 
 func (r *reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -132,11 +149,33 @@ func (r *reconciler) runEnvoyServer(req ctrl.Request, object v1alpha1.Authorizat
 			defer cancel()
 			return fmt.Errorf("failed to wait for cache sync")
 		}
-		src, err := buildSources(mgr, envoyCompiler, object)
+		composite, err := buildSources(mgr, envoyCompiler, object)
 		if err != nil {
 			return fmt.Errorf("failed to build engine source: %w", err)
 		}
-		grpc := envoy.NewServer(object.Spec.Type.Envoy.Network, object.Spec.Type.Envoy.Address, src, dynclient)
+		// track the source status
+		src := sources.NewTracked(req.String(), composite, nil)
+		unregister := sources.Default().Register(req.String(), src)
+		// track policies readiness
+		readiness, watcher := probes.WatchSource(src, r.strict)
+		group.StartWithContext(ctx, watcher)
+		envoyConfig := envoy.Config{
+			Network:    object.Spec.Type.Envoy.Network,
+			Address:    object.Spec.Type.Envoy.Address,
+			Reflection: r.reflection,
+			Ready:      readiness.Ready,
+		}
+		if tls := object.Spec.Type.Envoy.TLS; tls != nil {
+			envoyConfig.CertFile = tls.CertFile
+			envoyConfig.KeyFile = tls.KeyFile
+			envoyConfig.ClientCAFile = tls.ClientCAFile
+			envoyConfig.ClientSANs = tls.AllowedClientSANs
+		}
+		if defaults := object.Spec.Defaults; defaults != nil {
+			envoyConfig.NoMatch = decision.FromAPI(defaults.NoMatch)
+			envoyConfig.SourceError = decision.FromAPI(defaults.SourceError)
+		}
+		grpc := envoy.NewServer(envoyConfig, src, dynclient)
 		group.StartWithContext(ctx, func(ctx context.Context) {
 			// grpc auth server
 			defer cancel()
@@ -144,12 +183,15 @@ func (r *reconciler) runEnvoyServer(req ctrl.Request, object v1alpha1.Authorizat
 		})
 		server = &entry{
 			cancel: func() error {
+				// stop tracking the source
+				unregister()
 				// cancel context
 				cancel()
 				// wait all tasks in the group are over
 				group.Wait()
 				return multierr.Combine(grpcErr, mgrErr)
 			},
+			ready: readiness.Ready,
 		}
 		r.servers[req] = server
 		return nil
@@ -214,17 +256,38 @@ func (r *reconciler) runHttpServer(req ctrl.Request, object v1alpha1.Authorizati
 			defer cancel()
 			return fmt.Errorf("failed to wait for cache sync")
 		}
-		src, err := buildSources(mgr, httpCompiler, object)
+		composite, err := buildSources(mgr, httpCompiler, object)
 		if err != nil {
 			return fmt.Errorf("failed to build engine source: %w", err)
 		}
+		// track the source status
+		src := sources.NewTracked(req.String(), composite, nil)
+		unregister := sources.Default().Register(req.String(), src)
+		// track policies readiness
+		readiness, watcher := probes.WatchSource(src, r.strict)
+		group.StartWithContext(ctx, watcher)
 		httpConfig := http.Config{
-			Address:          object.Spec.Type.HTTP.Address,
-			NestedRequest:    object.Spec.Type.HTTP.NestedRequest,
-			InputExpression:  object.Spec.Type.HTTP.Modifiers.Request,
-			OutputExpression: object.Spec.Type.HTTP.Modifiers.Response,
-			CertFile:         r.certFile,
-			KeyFile:          r.keyFile,
+			Address:       object.Spec.Type.HTTP.Address,
+			NestedRequest: object.Spec.Type.HTTP.NestedRequest,
+			Adapter:       object.Spec.Type.HTTP.Adapter,
+			CertFile:      r.certFile,
+			KeyFile:       r.keyFile,
+			// the resource doesn't expose limits, use the server defaults
+			MaxBodySize:       http.DefaultMaxBodySize,
+			MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+			ReadTimeout:       http.DefaultReadTimeout,
+			ReadHeaderTimeout: http.DefaultReadHeaderTimeout,
+			WriteTimeout:      http.DefaultWriteTimeout,
+			IdleTimeout:       http.DefaultIdleTimeout,
+			RetryAfter:        http.DefaultRetryAfter,
+		}
+		if modifiers := object.Spec.Type.HTTP.Modifiers; modifiers != nil {
+			httpConfig.InputExpression = modifiers.Request
+			httpConfig.OutputExpression = modifiers.Response
+		}
+		if defaults := object.Spec.Defaults; defaults != nil {
+			httpConfig.NoMatch = decision.FromAPI(defaults.NoMatch)
+			httpConfig.SourceError = decision.FromAPI(defaults.SourceError)
 		}
 		http := http.NewServer(httpConfig, src, dynclient)
 		group.StartWithContext(ctx, func(ctx context.Context) {
@@ -234,12 +297,15 @@ func (r *reconciler) runHttpServer(req ctrl.Request, object v1alpha1.Authorizati
 		})
 		server = &entry{
 			cancel: func() error {
+				// stop tracking the source
+				unregister()
 				// cancel context
 				cancel()
 				// wait all tasks in the group are over
 				group.Wait()
 				return multierr.Combine(grpcErr, mgrErr)
 			},
+			ready: readiness.Ready,
 		}
 		r.servers[req] = server
 		return nil
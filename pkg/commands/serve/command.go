@@ -3,7 +3,9 @@ package serve
 import (
 	controlplane "github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/control-plane"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/envoy"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/extproc"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/kubernetes"
 	sidecarinjector "github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/sidecar-injector"
 	"github.com/spf13/cobra"
 )
@@ -14,7 +16,9 @@ func Command() *cobra.Command {
 		Short: "Run Kyverno Authz servers",
 	}
 	command.AddCommand(envoy.Command())
+	command.AddCommand(extproc.Command())
 	command.AddCommand(http.Command())
+	command.AddCommand(kubernetes.Command())
 	command.AddCommand(controlplane.Command())
 	command.AddCommand(sidecarinjector.Command())
 	return command
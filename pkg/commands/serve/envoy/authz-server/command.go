@@ -2,51 +2,37 @@ package authzserver
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"os"
+	"time"
 
 	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
-	"github.com/google/go-containerregistry/pkg/name"
-	"github.com/google/go-containerregistry/pkg/v1/remote"
-	"github.com/hairyhenderson/go-fsimpl"
-	"github.com/hairyhenderson/go-fsimpl/filefs"
-	"github.com/hairyhenderson/go-fsimpl/gitfs"
 	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	decisioncache "github.com/kyverno/kyverno-envoy-plugin/pkg/authz/cache"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/envoy"
-	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/setup"
 	vpolcompiler "github.com/kyverno/kyverno-envoy-plugin/pkg/engine/compiler"
-	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/sources"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/probes"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/signals"
-	"github.com/kyverno/kyverno-envoy-plugin/pkg/utils/ocifs"
-	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
-	sdksources "github.com/kyverno/kyverno-envoy-plugin/sdk/core/sources"
-	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
 	"github.com/spf13/cobra"
 	"go.uber.org/multierr"
-	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
-	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/cache"
-	"sigs.k8s.io/controller-runtime/pkg/client"
-	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
 
 func Command() *cobra.Command {
 	var probesAddress string
-	var metricsAddress string
 	var grpcAddress string
 	var grpcNetwork string
-	var kubeConfigOverrides clientcmd.ConfigOverrides
-	var externalPolicySources []string
-	var kubePolicySource bool
-	var imagePullSecrets []string
-	var allowInsecureRegistry bool
+	var grpcCertFile string
+	var grpcKeyFile string
+	var grpcClientCAFile string
+	var grpcAllowedClientSANs []string
+	var grpcReflection bool
+	var strict bool
+	var flags setup.Flags
+	var noMatchDecision decision.Decision
+	var sourceErrorDecision decision.Decision
+	var decisionCache decisioncache.Config
 	command := &cobra.Command{
 		Use:   "authz-server",
 		Short: "Start the Kyverno Authz Server",
@@ -54,17 +40,9 @@ func Command() *cobra.Command {
 			// setup signals aware context
 			return signals.Do(context.Background(), func(ctx context.Context) error {
 				// track errors
-				var probesErr, grpcErr, envoyMgrErr error
+				var env *setup.Env
+				var probesErr, grpcErr error
 				err := func(ctx context.Context) error {
-					// create a rest config
-					kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-						clientcmd.NewDefaultClientConfigLoadingRules(),
-						&kubeConfigOverrides,
-					)
-					config, err := kubeConfig.ClientConfig()
-					if err != nil {
-						return err
-					}
 					// create a cancellable context
 					ctx, cancel := context.WithCancel(ctx)
 					// cancel context at the end
@@ -73,84 +51,34 @@ func Command() *cobra.Command {
 					var group wait.Group
 					// wait all tasks in the group are over
 					defer group.Wait()
-					secrets := make([]string, 0)
-					if len(imagePullSecrets) > 0 {
-						secrets = append(secrets, imagePullSecrets...)
-					}
-					dynclient, err := dynamic.NewForConfig(config)
+					var err error
+					env, err = setup.New(ctx, &flags, &group, cancel)
 					if err != nil {
 						return err
 					}
-					// Create kubernetes client
-					kubeclient, err := kubernetes.NewForConfig(config)
-					if err != nil {
-						return err
-					}
-					namespace, _, err := kubeConfig.Namespace()
-					if err != nil {
-						return fmt.Errorf("failed to get namespace from kubeconfig: %w", err)
-					}
-					if namespace == "" || namespace == "default" {
-						// Log a warning or require explicit namespace setting
-						log.Printf("Using namespace '%s' - consider setting explicit namespace", namespace)
-					}
-					rOpts, nOpts, err := ocifs.RegistryOpts(kubeclient.CoreV1().Secrets(namespace), allowInsecureRegistry, secrets...)
-					if err != nil {
-						log.Fatalf("failed to initialize registry opts: %v", err)
-						os.Exit(1)
-					}
 					// initialize compiler
 					envoyCompiler := vpolcompiler.NewCompiler[dynamic.Interface, *authv3.CheckRequest, *authv3.CheckResponse]()
-					extForEnvoy, err := getExternalProviders(envoyCompiler, nOpts, rOpts, externalPolicySources...)
+					envoyProvider, err := setup.PolicySource(ctx, env, "envoy", v1alpha1.EvaluationModeEnvoy, envoyCompiler)
 					if err != nil {
 						return err
 					}
-					envoyProvider := sdksources.NewComposite(extForEnvoy...)
-					// if kube policy source is enabled
-					if kubePolicySource {
-						// create a controller manager
-						scheme := runtime.NewScheme()
-						if err := vpol.Install(scheme); err != nil {
-							return err
-						}
-						envoyMgr, err := ctrl.NewManager(config, ctrl.Options{
-							Scheme: scheme,
-							Metrics: metricsserver.Options{
-								BindAddress: metricsAddress,
-							},
-							Cache: cache.Options{
-								ByObject: map[client.Object]cache.ByObject{
-									&vpol.ValidatingPolicy{}: {
-										Field: fields.OneTermEqualSelector("spec.evaluation.mode", string(v1alpha1.EvaluationModeEnvoy)),
-									},
-								},
-							},
-						})
-						if err != nil {
-							return fmt.Errorf("failed to construct manager: %w", err)
-						}
-						envoySource, err := sources.NewKube("envoy", envoyMgr, envoyCompiler)
-						if err != nil {
-							return fmt.Errorf("failed to create envoy source: %w", err)
-						}
-						envoyProvider = sdksources.NewComposite(envoySource, envoyProvider)
-						if err != nil {
-							return fmt.Errorf("failed to construct manager: %w", err)
-						}
-						// start managers
-						group.StartWithContext(ctx, func(ctx context.Context) {
-							// cancel context at the end
-							defer cancel()
-							envoyMgrErr = envoyMgr.Start(ctx)
-						})
-						if !envoyMgr.GetCache().WaitForCacheSync(ctx) {
-							defer cancel()
-							return fmt.Errorf("failed to wait for envoy cache sync")
-						}
-					}
+					// track policies readiness
+					ready := setup.Ready(ctx, env, envoyProvider, strict)
 					// create http and grpc servers
-					probesServer := probes.NewServer(probesAddress)
-					grpc := envoy.NewServer(grpcNetwork, grpcAddress, envoyProvider, dynclient)
+					probesServer := probes.NewServer(probesAddress, ready)
+					grpc := envoy.NewServer(envoy.Config{
+						Network:      grpcNetwork,
+						Address:      grpcAddress,
+						CertFile:     grpcCertFile,
+						KeyFile:      grpcKeyFile,
+						ClientCAFile: grpcClientCAFile,
+						ClientSANs:   grpcAllowedClientSANs,
+						Reflection:   grpcReflection,
+						Ready:        ready,
+						NoMatch:      noMatchDecision,
+						SourceError:  sourceErrorDecision,
+						Cache:        decisionCache,
+					}, envoyProvider, env.Dynamic)
 					// run servers
 					group.StartWithContext(ctx, func(ctx context.Context) {
 						// probes
@@ -164,44 +92,25 @@ func Command() *cobra.Command {
 					})
 					return nil
 				}(ctx)
-				return multierr.Combine(err, probesErr, grpcErr, envoyMgrErr)
+				return multierr.Combine(err, probesErr, grpcErr, env.Err())
 			})
 		},
 	}
 	command.Flags().StringVar(&probesAddress, "probes-address", ":9080", "Address to listen on for health checks")
 	command.Flags().StringVar(&grpcAddress, "grpc-address", ":9081", "Address to listen on")
 	command.Flags().StringVar(&grpcNetwork, "grpc-network", "tcp", "Network to listen on")
-	command.Flags().StringVar(&metricsAddress, "metrics-address", ":9082", "Address to listen on for metrics")
-	command.Flags().StringArrayVar(&externalPolicySources, "external-policy-source", nil, "External policy sources")
-	command.Flags().StringArrayVar(&imagePullSecrets, "image-pull-secret", nil, "Image pull secrets")
-	command.Flags().BoolVar(&allowInsecureRegistry, "allow-insecure-registry", false, "Allow insecure registry")
-	command.Flags().BoolVar(&kubePolicySource, "kube-policy-source", true, "Enable in-cluster kubernetes policy source")
-	clientcmd.BindOverrideFlags(&kubeConfigOverrides, command.Flags(), clientcmd.RecommendedConfigOverrideFlags("kube-"))
+	command.Flags().StringVar(&grpcCertFile, "grpc-cert-file", "", "File containing the gRPC server certificate, enables TLS (reloaded when it changes)")
+	command.Flags().StringVar(&grpcKeyFile, "grpc-key-file", "", "File containing the gRPC server private key (reloaded when it changes)")
+	command.Flags().StringVar(&grpcClientCAFile, "grpc-client-ca-file", "", "File containing the CA bundle used to verify client certificates, enables mutual TLS (reloaded when it changes)")
+	command.Flags().StringArrayVar(&grpcAllowedClientSANs, "grpc-allowed-client-san", nil, "Allowed client certificate SANs (wildcards are supported), requires a client CA file")
+	command.Flags().BoolVar(&grpcReflection, "grpc-reflection", true, "Enable the gRPC reflection service")
+	command.Flags().BoolVar(&strict, "strict", false, "Report the server as not ready when policies fail to load or compile")
+	command.Flags().Var(&noMatchDecision, "no-match-decision", "Decision taken when no policy produced a result (allow, deny, deny:<status> or deny:<status>:<body>)")
+	command.Flags().Var(&sourceErrorDecision, "source-error-decision", "Decision taken when policy sources failed to load and no policy produced a result (allow, deny, deny:<status> or deny:<status>:<body>)")
+	command.Flags().IntVar(&decisionCache.Size, "decision-cache-size", 0, "Maximum number of cached decisions (0 disables the decision cache)")
+	command.Flags().DurationVar(&decisionCache.TTL, "decision-cache-ttl", 5*time.Second, "Duration decisions are cached for")
+	command.Flags().StringVar(&decisionCache.Key, "decision-cache-key", "", "CEL expression computing the decision cache key of a request (available as object), requests with the same key get the same decision")
+	flags.AddFlags(command.Flags())
 
 	return command
 }
-
-func getExternalProviders[POLICY any](vpolCompiler engine.Compiler[POLICY], nOpts []name.Option, rOpts []remote.Option, urls ...string) ([]core.Source[POLICY], error) {
-	mux := fsimpl.NewMux()
-	mux.Add(filefs.FS)
-	// mux.Add(httpfs.FS)
-	// mux.Add(blobfs.FS)
-	mux.Add(gitfs.FS)
-
-	// Create a configured ocifs.FS with registry options
-	configuredOCIFS := ocifs.ConfigureOCIFS(nOpts, rOpts)
-	mux.Add(configuredOCIFS)
-
-	var providers []core.Source[POLICY]
-	for _, url := range urls {
-		fsys, err := mux.Lookup(url)
-		if err != nil {
-			return nil, err
-		}
-		providers = append(
-			providers,
-			sdksources.NewOnce(sources.NewFs(fsys, vpolCompiler)),
-		)
-	}
-	return providers, nil
-}
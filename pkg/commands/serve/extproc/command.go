@@ -0,0 +1,15 @@
+package extproc
+
+import (
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/extproc/processor"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "extproc",
+		Short: "Run Kyverno Envoy external processing servers",
+	}
+	command.AddCommand(processor.Command())
+	return command
+}
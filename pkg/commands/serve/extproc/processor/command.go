@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"context"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/extproc"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/setup"
+	vpolcompiler "github.com/kyverno/kyverno-envoy-plugin/pkg/engine/compiler"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/probes"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/signals"
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+func Command() *cobra.Command {
+	var probesAddress string
+	var grpcAddress string
+	var grpcNetwork string
+	var grpcCertFile string
+	var grpcKeyFile string
+	var grpcClientCAFile string
+	var grpcAllowedClientSANs []string
+	var grpcReflection bool
+	var strict bool
+	var flags setup.Flags
+	var noMatchDecision decision.Decision
+	var sourceErrorDecision decision.Decision
+	command := &cobra.Command{
+		Use:   "processor",
+		Short: "Start the Kyverno External Processor",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// setup signals aware context
+			return signals.Do(context.Background(), func(ctx context.Context) error {
+				// track errors
+				var env *setup.Env
+				var probesErr, grpcErr error
+				err := func(ctx context.Context) error {
+					// create a cancellable context
+					ctx, cancel := context.WithCancel(ctx)
+					// cancel context at the end
+					defer cancel()
+					// create a wait group
+					var group wait.Group
+					// wait all tasks in the group are over
+					defer group.Wait()
+					var err error
+					env, err = setup.New(ctx, &flags, &group, cancel)
+					if err != nil {
+						return err
+					}
+					// initialize compiler
+					extProcCompiler := vpolcompiler.NewCompiler[dynamic.Interface, *extprocv3.ProcessingRequest, *extprocv3.ProcessingResponse]()
+					extProcProvider, err := setup.PolicySource(ctx, env, "extproc", v1alpha1.EvaluationModeExtProc, extProcCompiler)
+					if err != nil {
+						return err
+					}
+					// track policies readiness
+					ready := setup.Ready(ctx, env, extProcProvider, strict)
+					// create http and grpc servers
+					probesServer := probes.NewServer(probesAddress, ready)
+					grpc := extproc.NewServer(extproc.Config{
+						Network:      grpcNetwork,
+						Address:      grpcAddress,
+						CertFile:     grpcCertFile,
+						KeyFile:      grpcKeyFile,
+						ClientCAFile: grpcClientCAFile,
+						ClientSANs:   grpcAllowedClientSANs,
+						Reflection:   grpcReflection,
+						Ready:        ready,
+						NoMatch:      noMatchDecision,
+						SourceError:  sourceErrorDecision,
+					}, extProcProvider, env.Dynamic)
+					// run servers
+					group.StartWithContext(ctx, func(ctx context.Context) {
+						// probes
+						defer cancel()
+						probesErr = probesServer.Run(ctx)
+					})
+					group.StartWithContext(ctx, func(ctx context.Context) {
+						// grpc processing server
+						defer cancel()
+						grpcErr = grpc.Run(ctx)
+					})
+					return nil
+				}(ctx)
+				return multierr.Combine(err, probesErr, grpcErr, env.Err())
+			})
+		},
+	}
+	command.Flags().StringVar(&probesAddress, "probes-address", ":9080", "Address to listen on for health checks")
+	command.Flags().StringVar(&grpcAddress, "grpc-address", ":9081", "Address to listen on")
+	command.Flags().StringVar(&grpcNetwork, "grpc-network", "tcp", "Network to listen on")
+	command.Flags().StringVar(&grpcCertFile, "grpc-cert-file", "", "File containing the gRPC server certificate, enables TLS (reloaded when it changes)")
+	command.Flags().StringVar(&grpcKeyFile, "grpc-key-file", "", "File containing the gRPC server private key (reloaded when it changes)")
+	command.Flags().StringVar(&grpcClientCAFile, "grpc-client-ca-file", "", "File containing the CA bundle used to verify client certificates, enables mutual TLS (reloaded when it changes)")
+	command.Flags().StringArrayVar(&grpcAllowedClientSANs, "grpc-allowed-client-san", nil, "Allowed client certificate SANs (wildcards are supported), requires a client CA file")
+	command.Flags().BoolVar(&grpcReflection, "grpc-reflection", true, "Enable the gRPC reflection service")
+	command.Flags().BoolVar(&strict, "strict", false, "Report the server as not ready when policies fail to load or compile")
+	command.Flags().Var(&noMatchDecision, "no-match-decision", "Decision taken when no policy produced a result (allow, deny, deny:<status> or deny:<status>:<body>)")
+	command.Flags().Var(&sourceErrorDecision, "source-error-decision", "Decision taken when policy sources failed to load and no policy produced a result (allow, deny, deny:<status> or deny:<status>:<body>)")
+	flags.AddFlags(command.Flags())
+
+	return command
+}
@@ -3,60 +3,62 @@ package authzserver
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/google/go-containerregistry/pkg/name"
-	"github.com/google/go-containerregistry/pkg/v1/remote"
-	"github.com/hairyhenderson/go-fsimpl"
-	"github.com/hairyhenderson/go-fsimpl/filefs"
-	"github.com/hairyhenderson/go-fsimpl/gitfs"
 	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	decisioncache "github.com/kyverno/kyverno-envoy-plugin/pkg/authz/cache"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/decision"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/http"
 	httplib "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/setup"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/control-plane/listener"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
 	vpolcompiler "github.com/kyverno/kyverno-envoy-plugin/pkg/engine/compiler"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/sources"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/probes"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/server"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/signals"
-	"github.com/kyverno/kyverno-envoy-plugin/pkg/utils/ocifs"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
-	sdksources "github.com/kyverno/kyverno-envoy-plugin/sdk/core/sources"
 	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
 	"github.com/spf13/cobra"
 	"go.uber.org/multierr"
-	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/cache"
-	"sigs.k8s.io/controller-runtime/pkg/client"
-	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
 
 func Command() *cobra.Command {
 	var probesAddress string
-	var metricsAddress string
 	var serverAddress string
-	var kubeConfigOverrides clientcmd.ConfigOverrides
-	var externalPolicySources []string
-	var kubePolicySource bool
-	var imagePullSecrets []string
-	var allowInsecureRegistry bool
+	var serverNetwork string
+	var probesNetwork string
+	var socketMode string
+	var flags setup.Flags
+	var noMatchDecision decision.Decision
+	var sourceErrorDecision decision.Decision
+	var decisionCache decisioncache.Config
 	var controlPlaneAddr string
 	var controlPlaneReconnectWait time.Duration
 	var controlPlaneMaxDialInterval time.Duration
 	var healthCheckInterval time.Duration
 	var nestedRequest bool
+	var h2c bool
 	var certFile string
 	var keyFile string
+	var adapter string
 	var inputExpression string
 	var outputExpression string
+	var maxBodySize int64
+	var maxHeaderBytes int
+	var readTimeout time.Duration
+	var readHeaderTimeout time.Duration
+	var writeTimeout time.Duration
+	var idleTimeout time.Duration
+	var maxConcurrentRequests int
+	var queueTimeout time.Duration
+	var retryAfter time.Duration
 	command := &cobra.Command{
 		Use:   "authz-server",
 		Short: "Start the Kyverno Authz Server",
@@ -64,17 +66,9 @@ func Command() *cobra.Command {
 			// setup signals aware context
 			return signals.Do(context.Background(), func(ctx context.Context) error {
 				// track errors
-				var probesErr, connErr, httpErr, httpMgrErr error
+				var env *setup.Env
+				var probesErr, connErr, httpErr error
 				err := func(ctx context.Context) error {
-					// create a rest config
-					kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-						clientcmd.NewDefaultClientConfigLoadingRules(),
-						&kubeConfigOverrides,
-					)
-					config, err := kubeConfig.ClientConfig()
-					if err != nil {
-						return err
-					}
 					// create a cancellable context
 					ctx, cancel := context.WithCancel(ctx)
 					// cancel context at the end
@@ -83,39 +77,14 @@ func Command() *cobra.Command {
 					var group wait.Group
 					// wait all tasks in the group are over
 					defer group.Wait()
-					secrets := make([]string, 0)
-					if len(imagePullSecrets) > 0 {
-						secrets = append(secrets, imagePullSecrets...)
-					}
-					dynclient, err := dynamic.NewForConfig(config)
-					if err != nil {
-						return err
-					}
-					// Create kubernetes client
-					kubeclient, err := kubernetes.NewForConfig(config)
+					var err error
+					env, err = setup.New(ctx, &flags, &group, cancel)
 					if err != nil {
 						return err
 					}
-					namespace, _, err := kubeConfig.Namespace()
-					if err != nil {
-						return fmt.Errorf("failed to get namespace from kubeconfig: %w", err)
-					}
-					if namespace == "" || namespace == "default" {
-						// Log a warning or require explicit namespace setting
-						log.Printf("Using namespace '%s' - consider setting explicit namespace", namespace)
-					}
-					rOpts, nOpts, err := ocifs.RegistryOpts(kubeclient.CoreV1().Secrets(namespace), allowInsecureRegistry, secrets...)
-					if err != nil {
-						log.Fatalf("failed to initialize registry opts: %v", err)
-						os.Exit(1)
-					}
 					// initialize compiler
 					httpCompiler := vpolcompiler.NewCompiler[dynamic.Interface, *httplib.CheckRequest, *httplib.CheckResponse]()
-					extForHTTP, err := getExternalProviders(httpCompiler, nOpts, rOpts, externalPolicySources...)
-					if err != nil {
-						return err
-					}
-					httpProvider := sdksources.NewComposite(extForHTTP...)
+					var extra []core.Source[engine.HTTPPolicy]
 					// if we have a control plane source
 					if controlPlaneAddr != "" {
 						httpListener := sources.NewListener()
@@ -133,6 +102,14 @@ func Command() *cobra.Command {
 							controlPlaneMaxDialInterval,
 							healthCheckInterval,
 						)
+						controlPlaneSource, err := sources.NewControlPlane(httpListener, httpCompiler)
+						if err != nil {
+							return fmt.Errorf("failed to create control plane source: %w", err)
+						}
+						// track the source status, it is synced once the control plane delivered policies
+						trackedControlPlaneSource := sources.NewTracked("control-plane", controlPlaneSource, httpListener.Synced)
+						sources.Default().Register("control-plane", trackedControlPlaneSource)
+						extra = append(extra, trackedControlPlaneSource)
 						group.StartWithContext(ctx, func(ctx context.Context) {
 							for {
 								select {
@@ -148,56 +125,41 @@ func Command() *cobra.Command {
 							}
 						})
 					}
-					// if kube policy source is enabled
-					if kubePolicySource {
-						// create a controller manager
-						scheme := runtime.NewScheme()
-						if err := vpol.Install(scheme); err != nil {
-							return err
-						}
-						httpMgr, err := ctrl.NewManager(config, ctrl.Options{
-							Scheme: scheme,
-							Metrics: metricsserver.Options{
-								BindAddress: metricsAddress,
-							},
-							Cache: cache.Options{
-								ByObject: map[client.Object]cache.ByObject{
-									&vpol.ValidatingPolicy{}: {
-										Field: fields.OneTermEqualSelector("spec.evaluation.mode", string(v1alpha1.EvaluationModeHTTP)),
-									},
-								},
-							},
-						})
-						if err != nil {
-							return fmt.Errorf("failed to construct manager: %w", err)
-						}
-						httpSource, err := sources.NewKube("http", httpMgr, httpCompiler)
-						if err != nil {
-							return fmt.Errorf("failed to create http source: %w", err)
-						}
-						httpProvider = sdksources.NewComposite(httpSource, httpProvider)
-						// start managers
-						group.StartWithContext(ctx, func(ctx context.Context) {
-							// cancel context at the end
-							defer cancel()
-							httpMgrErr = httpMgr.Start(ctx)
-						})
-						if !httpMgr.GetCache().WaitForCacheSync(ctx) {
-							defer cancel()
-							return fmt.Errorf("failed to wait for http cache sync")
-						}
+					httpProvider, err := setup.PolicySource(ctx, env, "http", v1alpha1.EvaluationModeHTTP, httpCompiler, extra...)
+					if err != nil {
+						return err
 					}
 					// create http and grpc servers
-					probesServer := probes.NewServer(probesAddress)
+					mode, err := server.ParseFileMode(socketMode)
+					if err != nil {
+						return err
+					}
+					probesServer := probes.NewServerWithNetwork(probesNetwork, probesAddress, mode)
 					httpConfig := http.Config{
-						Address:          serverAddress,
-						NestedRequest:    nestedRequest,
-						CertFile:         certFile,
-						KeyFile:          keyFile,
-						InputExpression:  inputExpression,
-						OutputExpression: outputExpression,
+						Network:               serverNetwork,
+						Address:               serverAddress,
+						SocketMode:            mode,
+						NestedRequest:         nestedRequest,
+						H2C:                   h2c,
+						Adapter:               adapter,
+						CertFile:              certFile,
+						KeyFile:               keyFile,
+						InputExpression:       inputExpression,
+						OutputExpression:      outputExpression,
+						NoMatch:               noMatchDecision,
+						SourceError:           sourceErrorDecision,
+						Cache:                 decisionCache,
+						MaxBodySize:           maxBodySize,
+						MaxHeaderBytes:        maxHeaderBytes,
+						ReadTimeout:           readTimeout,
+						ReadHeaderTimeout:     readHeaderTimeout,
+						WriteTimeout:          writeTimeout,
+						IdleTimeout:           idleTimeout,
+						MaxConcurrentRequests: maxConcurrentRequests,
+						QueueTimeout:          queueTimeout,
+						RetryAfter:            retryAfter,
 					}
-					httpAuthServer := http.NewServer(httpConfig, httpProvider, dynclient) // run servers
+					httpAuthServer := http.NewServer(httpConfig, httpProvider, env.Dynamic) // run servers
 					group.StartWithContext(ctx, func(ctx context.Context) {
 						// probes
 						defer cancel()
@@ -209,52 +171,41 @@ func Command() *cobra.Command {
 					})
 					return nil
 				}(ctx)
-				return multierr.Combine(err, probesErr, httpErr, httpMgrErr)
+				return multierr.Combine(err, probesErr, httpErr, env.Err())
 			})
 		},
 	}
 	command.Flags().StringVar(&probesAddress, "probes-address", ":9080", "Address to listen on for health checks")
-	command.Flags().StringVar(&metricsAddress, "metrics-address", ":9082", "Address to listen on for metrics")
-	command.Flags().StringArrayVar(&externalPolicySources, "external-policy-source", nil, "External policy sources")
-	command.Flags().StringArrayVar(&imagePullSecrets, "image-pull-secret", nil, "Image pull secrets")
-	command.Flags().BoolVar(&allowInsecureRegistry, "allow-insecure-registry", false, "Allow insecure registry")
-	command.Flags().BoolVar(&kubePolicySource, "kube-policy-source", true, "Enable in-cluster kubernetes policy source")
 	command.Flags().StringVar(&serverAddress, "server-address", ":9083", "Address to serve the http authorization server on")
+	command.Flags().StringVar(&serverNetwork, "server-network", "tcp", "Network to serve the http authorization server on (tcp or unix, the address is the socket path, @ prefixed paths are abstract sockets)")
+	command.Flags().StringVar(&probesNetwork, "probes-network", "tcp", "Network to listen on for health checks (tcp or unix)")
+	command.Flags().StringVar(&socketMode, "socket-mode", "", "Octal file mode of unix sockets, 0660 for example (defaults to the process umask)")
+	command.Flags().BoolVar(&h2c, "h2c", false, "Accept HTTP/2 over cleartext connections (prior knowledge and HTTP/1.1 upgrade)")
 	command.Flags().BoolVar(&nestedRequest, "nested-request", false, "Expect the requests to validate to be in the body of the original request")
 	command.Flags().DurationVar(&controlPlaneReconnectWait, "control-plane-reconnect-wait", 3*time.Second, "Duration to wait before retrying connecting to the control plane")
 	command.Flags().DurationVar(&controlPlaneMaxDialInterval, "control-plane-max-dial-interval", 8*time.Second, "Duration to wait before stopping attempts of sending a policy to a client")
 	command.Flags().DurationVar(&healthCheckInterval, "health-check-interval", 30*time.Second, "Interval for sending health checks")
 	command.Flags().StringVar(&controlPlaneAddr, "control-plane-address", "", "Control plane address")
+	command.Flags().StringVar(&adapter, "adapter", "", fmt.Sprintf("Proxy forward auth adapter providing default input and output expressions (%s)", strings.Join(http.Adapters(), ", ")))
 	command.Flags().StringVar(&inputExpression, "input-expression", "", "CEL expression for transforming the incoming request")
 	command.Flags().StringVar(&outputExpression, "output-expression", "", "CEL expression for transforming responses before being sent to clients")
 	command.Flags().StringVar(&certFile, "cert-file", "", "File containing tls certificate")
 	command.Flags().StringVar(&keyFile, "key-file", "", "File containing tls private key")
-	clientcmd.BindOverrideFlags(&kubeConfigOverrides, command.Flags(), clientcmd.RecommendedConfigOverrideFlags("kube-"))
+	command.Flags().Var(&noMatchDecision, "no-match-decision", "Decision taken when no policy produced a result (allow, deny, deny:<status> or deny:<status>:<body>)")
+	command.Flags().Var(&sourceErrorDecision, "source-error-decision", "Decision taken when policy sources failed to load and no policy produced a result (allow, deny, deny:<status> or deny:<status>:<body>)")
+	command.Flags().IntVar(&decisionCache.Size, "decision-cache-size", 0, "Maximum number of cached decisions (0 disables the decision cache)")
+	command.Flags().DurationVar(&decisionCache.TTL, "decision-cache-ttl", 5*time.Second, "Duration decisions are cached for")
+	command.Flags().StringVar(&decisionCache.Key, "decision-cache-key", "", "CEL expression computing the decision cache key of a request (available as object), requests with the same key get the same decision")
+	command.Flags().Int64Var(&maxBodySize, "max-body-size", http.DefaultMaxBodySize, "Maximum size of request bodies in bytes, larger requests are rejected with 413 (0 means no limit)")
+	command.Flags().IntVar(&maxHeaderBytes, "max-header-bytes", http.DefaultMaxHeaderBytes, "Maximum size of request headers in bytes")
+	command.Flags().DurationVar(&readTimeout, "read-timeout", http.DefaultReadTimeout, "Maximum duration for reading an entire request, including the body (0 means no timeout)")
+	command.Flags().DurationVar(&readHeaderTimeout, "read-header-timeout", http.DefaultReadHeaderTimeout, "Maximum duration for reading request headers (0 means no timeout)")
+	command.Flags().DurationVar(&writeTimeout, "write-timeout", http.DefaultWriteTimeout, "Maximum duration before timing out writes of the response (0 means no timeout)")
+	command.Flags().DurationVar(&idleTimeout, "idle-timeout", http.DefaultIdleTimeout, "Maximum duration to wait for the next request on keep-alive connections (0 means no timeout)")
+	command.Flags().IntVar(&maxConcurrentRequests, "max-concurrent-requests", 0, "Maximum number of requests processed concurrently, extra requests are rejected with 503 (0 means no limit)")
+	command.Flags().DurationVar(&queueTimeout, "queue-timeout", 0, "Maximum duration a request waits for a processing slot before being rejected when the concurrency limit is reached")
+	command.Flags().DurationVar(&retryAfter, "retry-after", http.DefaultRetryAfter, "Delay advertised in the Retry-After header of requests rejected by the concurrency limit")
+	flags.AddFlags(command.Flags())
 
 	return command
 }
-
-func getExternalProviders[POLICY any](vpolCompiler engine.Compiler[POLICY], nOpts []name.Option, rOpts []remote.Option, urls ...string) ([]core.Source[POLICY], error) {
-	mux := fsimpl.NewMux()
-	mux.Add(filefs.FS)
-	// mux.Add(httpfs.FS)
-	// mux.Add(blobfs.FS)
-	mux.Add(gitfs.FS)
-
-	// Create a configured ocifs.FS with registry options
-	configuredOCIFS := ocifs.ConfigureOCIFS(nOpts, rOpts)
-	mux.Add(configuredOCIFS)
-
-	var providers []core.Source[POLICY]
-	for _, url := range urls {
-		fsys, err := mux.Lookup(url)
-		if err != nil {
-			return nil, err
-		}
-		providers = append(
-			providers,
-			sdksources.NewOnce(sources.NewFs(fsys, vpolCompiler)),
-		)
-	}
-	return providers, nil
-}
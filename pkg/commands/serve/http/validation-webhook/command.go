@@ -6,7 +6,9 @@ import (
 
 	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
 	vpolcompiler "github.com/kyverno/kyverno-envoy-plugin/pkg/engine/compiler"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/sources"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/probes"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/signals"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/webhook/validation"
@@ -25,6 +27,7 @@ import (
 func Command() *cobra.Command {
 	var probesAddress string
 	var metricsAddress string
+	var kubeFunctionLibrarySource bool
 	var kubeConfigOverrides clientcmd.ConfigOverrides
 	command := &cobra.Command{
 		Use:   "validation-webhook",
@@ -53,6 +56,11 @@ func Command() *cobra.Command {
 					if err := vpol.Install(scheme); err != nil {
 						return err
 					}
+					if kubeFunctionLibrarySource {
+						if err := v1alpha1.Install(scheme); err != nil {
+							return err
+						}
+					}
 					mgr, err := ctrl.NewManager(config, ctrl.Options{
 						Scheme: scheme,
 						Metrics: metricsserver.Options{
@@ -63,6 +71,12 @@ func Command() *cobra.Command {
 					if err != nil {
 						return fmt.Errorf("failed to construct manager: %w", err)
 					}
+					// watch shared functions so that policies using them can be validated
+					if kubeFunctionLibrarySource {
+						if err := sources.NewKubeFunctionLibraries(mgr, functions.Default()); err != nil {
+							return fmt.Errorf("failed to create function libraries source: %w", err)
+						}
+					}
 					httpCompiler := vpolcompiler.NewCompiler[dynamic.Interface, *http.CheckRequest, *http.CheckResponse]()
 					vpolCompileFunc := func(policy *vpol.ValidatingPolicy) field.ErrorList {
 						if policy.Spec.EvaluationMode() == v1alpha1.EvaluationModeHTTP {
@@ -100,6 +114,7 @@ func Command() *cobra.Command {
 	}
 	command.Flags().StringVar(&probesAddress, "probes-address", ":9080", "Address to listen on for health checks")
 	command.Flags().StringVar(&metricsAddress, "metrics-address", ":9082", "Address to listen on for metrics")
+	command.Flags().BoolVar(&kubeFunctionLibrarySource, "kube-function-library-source", false, "Enable in-cluster FunctionLibrary source (requires the FunctionLibrary CRD)")
 	clientcmd.BindOverrideFlags(&kubeConfigOverrides, command.Flags(), clientcmd.RecommendedConfigOverrideFlags("kube-"))
 	return command
 }
@@ -0,0 +1,95 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/authz/sar"
+	sarcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/setup"
+	vpolcompiler "github.com/kyverno/kyverno-envoy-plugin/pkg/engine/compiler"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/probes"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/signals"
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+func Command() *cobra.Command {
+	var probesAddress string
+	var serverAddress string
+	var certFile string
+	var keyFile string
+	var clientCAFile string
+	var allowedClientSANs []string
+	var strict bool
+	var flags setup.Flags
+	command := &cobra.Command{
+		Use:   "authorizer",
+		Short: "Start the Kyverno Kubernetes webhook authorizer",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// setup signals aware context
+			return signals.Do(context.Background(), func(ctx context.Context) error {
+				// track errors
+				var env *setup.Env
+				var probesErr, serverErr error
+				err := func(ctx context.Context) error {
+					// create a cancellable context
+					ctx, cancel := context.WithCancel(ctx)
+					// cancel context at the end
+					defer cancel()
+					// create a wait group
+					var group wait.Group
+					// wait all tasks in the group are over
+					defer group.Wait()
+					var err error
+					env, err = setup.New(ctx, &flags, &group, cancel)
+					if err != nil {
+						return err
+					}
+					// initialize compiler
+					sarCompiler := vpolcompiler.NewCompiler[dynamic.Interface, *sarcel.CheckRequest, *sarcel.CheckResponse]()
+					sarProvider, err := setup.PolicySource(ctx, env, "subject-access-review", v1alpha1.EvaluationModeSubjectAccessReview, sarCompiler)
+					if err != nil {
+						return err
+					}
+					// track policies readiness
+					ready := setup.Ready(ctx, env, sarProvider, strict)
+					// create http servers
+					probesServer := probes.NewServer(probesAddress, ready)
+					authorizer := sar.NewServer(sar.Config{
+						Address:      serverAddress,
+						CertFile:     certFile,
+						KeyFile:      keyFile,
+						ClientCAFile: clientCAFile,
+						ClientSANs:   allowedClientSANs,
+					}, sarProvider, env.Dynamic)
+					// run servers
+					group.StartWithContext(ctx, func(ctx context.Context) {
+						// probes
+						defer cancel()
+						probesErr = probesServer.Run(ctx)
+					})
+					group.StartWithContext(ctx, func(ctx context.Context) {
+						// webhook authorizer
+						defer cancel()
+						serverErr = authorizer.Run(ctx)
+					})
+					return nil
+				}(ctx)
+				return multierr.Combine(err, probesErr, serverErr, env.Err())
+			})
+		},
+	}
+	command.Flags().StringVar(&probesAddress, "probes-address", ":9080", "Address to listen on for health checks")
+	command.Flags().StringVar(&serverAddress, "server-address", ":9083", "Address to serve the webhook authorizer on")
+	command.Flags().StringVar(&certFile, "cert-file", "", "File containing the server certificate, enables TLS (reloaded when it changes)")
+	command.Flags().StringVar(&keyFile, "key-file", "", "File containing the server private key (reloaded when it changes)")
+	command.Flags().StringVar(&clientCAFile, "client-ca-file", "", "File containing the CA bundle used to verify API server client certificates, enables mutual TLS (reloaded when it changes)")
+	command.Flags().StringArrayVar(&allowedClientSANs, "allowed-client-san", nil, "Allowed client certificate SANs (wildcards are supported), requires a client CA file")
+	command.Flags().BoolVar(&strict, "strict", false, "Report the server as not ready when policies fail to load or compile")
+	flags.AddFlags(command.Flags())
+
+	return command
+}
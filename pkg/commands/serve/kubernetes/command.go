@@ -0,0 +1,15 @@
+package kubernetes
+
+import (
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/commands/serve/kubernetes/authorizer"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "kubernetes",
+		Short: "Run Kyverno Kubernetes authorization servers",
+	}
+	command.AddCommand(authorizer.Command())
+	return command
+}
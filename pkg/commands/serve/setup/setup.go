@@ -0,0 +1,324 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/hairyhenderson/go-fsimpl"
+	"github.com/hairyhenderson/go-fsimpl/filefs"
+	"github.com/hairyhenderson/go-fsimpl/gitfs"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/documents"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/sources"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/variables"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/ipset"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/probes"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/utils/ocifs"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	sdksources "github.com/kyverno/kyverno-envoy-plugin/sdk/core/sources"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+	"github.com/spf13/pflag"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// Flags holds the flags shared by the authorization servers: kubernetes access, policy, data and ip set sources.
+type Flags struct {
+	MetricsAddress            string
+	KubeConfigOverrides       clientcmd.ConfigOverrides
+	ExternalPolicySources     []string
+	KubePolicySource          bool
+	ImagePullSecrets          []string
+	AllowInsecureRegistry     bool
+	HTTPClientTimeout         time.Duration
+	HTTPClientCAFile          string
+	IPSets                    []string
+	IPSetRefreshInterval      time.Duration
+	DataSources               []string
+	DataRefreshInterval       time.Duration
+	KubeDataSource            bool
+	KubeFunctionLibrarySource bool
+}
+
+// AddFlags registers the shared flags.
+func (f *Flags) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&f.MetricsAddress, "metrics-address", ":9082", "Address to listen on for metrics")
+	flags.StringArrayVar(&f.ExternalPolicySources, "external-policy-source", nil, "External policy sources")
+	flags.StringArrayVar(&f.ImagePullSecrets, "image-pull-secret", nil, "Image pull secrets")
+	flags.BoolVar(&f.AllowInsecureRegistry, "allow-insecure-registry", false, "Allow insecure registry")
+	flags.DurationVar(&f.HTTPClientTimeout, "http-client-timeout", variables.DefaultHTTPTimeout, "Timeout of requests made by policies with the http library (0 disables the timeout)")
+	flags.StringVar(&f.HTTPClientCAFile, "http-client-ca-file", "", "File containing CAs trusted by the http library in addition to the system CAs")
+	flags.BoolVar(&f.KubePolicySource, "kube-policy-source", true, "Enable in-cluster kubernetes policy source")
+	flags.StringArrayVar(&f.IPSets, "ip-set", nil, "Named IP sets in the form name=url (same url schemes as external policy sources)")
+	flags.DurationVar(&f.IPSetRefreshInterval, "ip-set-refresh-interval", time.Minute, "Interval for reloading IP sets")
+	flags.StringArrayVar(&f.DataSources, "data-source", nil, "External data document sources (same url schemes as external policy sources)")
+	flags.DurationVar(&f.DataRefreshInterval, "data-refresh-interval", time.Minute, "Interval for reloading data documents from data sources (data ConfigMaps are watched)")
+	flags.BoolVar(&f.KubeDataSource, "kube-data-source", false, "Enable in-cluster data documents from ConfigMaps labelled with authz.kyverno.io/data=true")
+	flags.BoolVar(&f.KubeFunctionLibrarySource, "kube-function-library-source", false, "Enable in-cluster FunctionLibrary source (requires the FunctionLibrary CRD)")
+	clientcmd.BindOverrideFlags(&f.KubeConfigOverrides, flags, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+}
+
+// Env holds the clients and the shared state of a server, background tasks are started in the group
+// and the context is cancelled when one of the controller managers stops.
+type Env struct {
+	flags     *Flags
+	group     *wait.Group
+	cancel    context.CancelFunc
+	config    *rest.Config
+	namespace string
+	mux       fsimpl.FSMux
+	// Dynamic is the dynamic client passed to policies
+	Dynamic dynamic.Interface
+	lock    sync.Mutex
+	errs    []error
+}
+
+// New creates the kubernetes clients, configures the registries and the contexts shared by policies
+// and starts reloading ip sets and data documents.
+func New(ctx context.Context, flags *Flags, group *wait.Group, cancel context.CancelFunc) (*Env, error) {
+	// create a rest config
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&flags.KubeConfigOverrides,
+	)
+	config, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	secrets := make([]string, 0)
+	if len(flags.ImagePullSecrets) > 0 {
+		secrets = append(secrets, flags.ImagePullSecrets...)
+	}
+	dynclient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	// Create kubernetes client
+	kubeclient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace from kubeconfig: %w", err)
+	}
+	if namespace == "" || namespace == "default" {
+		// Log a warning or require explicit namespace setting
+		log.Printf("Using namespace '%s' - consider setting explicit namespace", namespace)
+	}
+	rOpts, nOpts, err := ocifs.RegistryOpts(kubeclient.CoreV1().Secrets(namespace), flags.AllowInsecureRegistry, secrets...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize registry opts: %w", err)
+	}
+	// configure the http and image data contexts shared by policies
+	if err := variables.Default().Configure(variables.Config{
+		HTTPTimeout:           flags.HTTPClientTimeout,
+		HTTPCAFile:            flags.HTTPClientCAFile,
+		Secrets:               kubeclient.CoreV1().Secrets(namespace),
+		ImagePullSecrets:      secrets,
+		AllowInsecureRegistry: flags.AllowInsecureRegistry,
+	}); err != nil {
+		return nil, err
+	}
+	mux := newMux(nOpts, rOpts)
+	// load ip sets
+	ipSetWatchers, err := ipset.WatchAll(ipset.Default(), mux.Lookup, flags.IPSetRefreshInterval, flags.IPSets...)
+	if err != nil {
+		return nil, err
+	}
+	for _, watcher := range ipSetWatchers {
+		group.StartWithContext(ctx, watcher)
+	}
+	// load data documents
+	if flags.KubeDataSource && !flags.KubePolicySource {
+		return nil, fmt.Errorf("kube data source requires the kube policy source to be enabled")
+	}
+	dataWatchers, err := documents.WatchAll(documents.Default(), mux.Lookup, flags.DataRefreshInterval, flags.DataSources...)
+	if err != nil {
+		return nil, err
+	}
+	for _, watcher := range dataWatchers {
+		group.StartWithContext(ctx, watcher)
+	}
+	return &Env{
+		flags:     flags,
+		group:     group,
+		cancel:    cancel,
+		config:    config,
+		namespace: namespace,
+		mux:       mux,
+		Dynamic:   dynclient,
+	}, nil
+}
+
+// Err returns the errors returned by the controller managers, it should be called once the group is over.
+func (e *Env) Err() error {
+	if e == nil {
+		return nil
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return multierr.Combine(e.errs...)
+}
+
+// Ready starts watching the readiness of source and returns the corresponding check.
+func Ready[POLICY any](ctx context.Context, env *Env, source core.Source[POLICY], strict bool) func() bool {
+	readiness, watcher := probes.WatchSource(source, strict)
+	env.group.StartWithContext(ctx, watcher)
+	return readiness.Ready
+}
+
+// PolicySource returns the policies of a server: policies of the given evaluation mode from the cluster (when enabled),
+// followed by the given sources and the external sources.
+// The controller manager watching the cluster is started and synced before returning.
+func PolicySource[POLICY any](
+	ctx context.Context,
+	env *Env,
+	name string,
+	mode vpol.EvaluationMode,
+	compiler engine.Compiler[POLICY],
+	extra ...core.Source[POLICY],
+) (core.Source[POLICY], error) {
+	external, err := getExternalProviders(compiler, env.mux, env.flags.ExternalPolicySources...)
+	if err != nil {
+		return nil, err
+	}
+	providers := append(append([]core.Source[POLICY]{}, extra...), external...)
+	// if kube policy source is enabled
+	if env.flags.KubePolicySource {
+		kubeSource, err := kubeSource(ctx, env, name, mode, compiler)
+		if err != nil {
+			return nil, err
+		}
+		providers = append([]core.Source[POLICY]{kubeSource}, providers...)
+	}
+	return sdksources.NewComposite(providers...), nil
+}
+
+// kubeSource creates and starts the controller manager watching the policies of the given evaluation mode.
+func kubeSource[POLICY any](ctx context.Context, env *Env, name string, mode vpol.EvaluationMode, compiler engine.Compiler[POLICY]) (core.Source[POLICY], error) {
+	flags := env.flags
+	// create a controller manager
+	scheme := runtime.NewScheme()
+	if err := vpol.Install(scheme); err != nil {
+		return nil, err
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if flags.KubeFunctionLibrarySource {
+		if err := v1alpha1.Install(scheme); err != nil {
+			return nil, err
+		}
+	}
+	byObject := map[client.Object]cache.ByObject{
+		&vpol.ValidatingPolicy{}: {
+			Field: fields.OneTermEqualSelector("spec.evaluation.mode", string(mode)),
+		},
+	}
+	if flags.KubeDataSource {
+		byObject[&corev1.ConfigMap{}] = cache.ByObject{
+			Label:      documents.Selector,
+			Namespaces: map[string]cache.Config{env.namespace: {}},
+		}
+	}
+	mgr, err := ctrl.NewManager(env.config, ctrl.Options{
+		Scheme: scheme,
+		Metrics: metricsserver.Options{
+			BindAddress: flags.MetricsAddress,
+		},
+		Cache: cache.Options{
+			ByObject: byObject,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct manager: %w", err)
+	}
+	if flags.KubeFunctionLibrarySource {
+		if err := sources.NewKubeFunctionLibraries(mgr, functions.Default()); err != nil {
+			return nil, fmt.Errorf("failed to create function libraries source: %w", err)
+		}
+	}
+	source, err := sources.NewKube(name, mgr, compiler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s source: %w", name, err)
+	}
+	// track the source status
+	tracked := sources.NewTracked("kube", source, nil)
+	sources.Default().Register("kube", tracked)
+	// start manager
+	env.group.StartWithContext(ctx, func(ctx context.Context) {
+		// cancel context at the end
+		defer env.cancel()
+		if err := mgr.Start(ctx); err != nil {
+			env.lock.Lock()
+			defer env.lock.Unlock()
+			env.errs = append(env.errs, err)
+		}
+	})
+	if !mgr.GetCache().WaitForCacheSync(ctx) {
+		env.cancel()
+		return nil, fmt.Errorf("failed to wait for %s cache sync", name)
+	}
+	if flags.KubeDataSource {
+		// data ConfigMaps are watched, changes are picked up as soon as they happen
+		if err := sources.NewKubeDocuments(ctx, mgr, documents.Default(), "kube", env.namespace); err != nil {
+			return nil, err
+		}
+	}
+	return tracked, nil
+}
+
+func newMux(nOpts []name.Option, rOpts []remote.Option) fsimpl.FSMux {
+	mux := fsimpl.NewMux()
+	mux.Add(filefs.FS)
+	// mux.Add(httpfs.FS)
+	// mux.Add(blobfs.FS)
+	mux.Add(gitfs.FS)
+
+	// Create a configured ocifs.FS with registry options
+	configuredOCIFS := ocifs.ConfigureOCIFS(nOpts, rOpts)
+	mux.Add(configuredOCIFS)
+	return mux
+}
+
+func getExternalProviders[POLICY any](vpolCompiler engine.Compiler[POLICY], mux fsimpl.FSMux, urls ...string) ([]core.Source[POLICY], error) {
+	var providers []core.Source[POLICY]
+	for _, url := range urls {
+		fsys, err := mux.Lookup(url)
+		if err != nil {
+			return nil, err
+		}
+		// register shared functions before policies get compiled
+		libraries, err := sources.NewFsFunctionLibraries(fsys).Load(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, library := range libraries {
+			functions.Default().Store(url+"#"+library.Name, library.Spec.Functions...)
+		}
+		// track the source status
+		provider := sources.NewTracked(url, sdksources.NewOnce(sources.NewFs(fsys, vpolCompiler)), nil)
+		sources.Default().Register(url, provider)
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
@@ -11,6 +11,7 @@ func TestCrds(t *testing.T) {
 	data, err := Crds()
 	assert.NoError(t, err)
 	files := []string{
+		"authz.kyverno.io_functionlibraries.yaml",
 		"policies.kyverno.io_validatingpolicies.yaml",
 	}
 	for _, file := range files {
@@ -0,0 +1,30 @@
+package documents
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DataLabel is the label identifying ConfigMaps exposed as data documents.
+const DataLabel = "authz.kyverno.io/data"
+
+// Selector selects the ConfigMaps exposed as data documents.
+var Selector = labels.SelectorFromSet(labels.Set{DataLabel: "true"})
+
+// FromKube returns a LoadFunc listing the ConfigMaps labelled with DataLabel in the given namespace.
+func FromKube(reader client.Reader, namespace string) LoadFunc {
+	return func(ctx context.Context) (map[string]any, [32]byte, error) {
+		var list corev1.ConfigMapList
+		if err := reader.List(ctx, &list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: Selector}); err != nil {
+			return nil, [32]byte{}, err
+		}
+		configMaps := make([]*corev1.ConfigMap, 0, len(list.Items))
+		for i := range list.Items {
+			configMaps = append(configMaps, &list.Items[i])
+		}
+		return FromConfigMaps(configMaps...)
+	}
+}
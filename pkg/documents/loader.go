@@ -0,0 +1,218 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kyverno/pkg/ext/file"
+	"github.com/kyverno/pkg/ext/yaml"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// LoadFunc loads a set of documents and returns them along with a hash of their content.
+type LoadFunc = func(context.Context) (map[string]any, [32]byte, error)
+
+// FromFS returns a LoadFunc reading the yaml and json files found in fsys.
+// Documents are nested according to their path, `tenants/acme.yaml` is exposed as `data.tenants.acme`.
+// A yaml file containing multiple documents is exposed as a list.
+// Hidden files and directories (like the ..data directory of mounted ConfigMaps) are skipped.
+func FromFS(fsys fs.FS) LoadFunc {
+	return func(context.Context) (map[string]any, [32]byte, error) {
+		hasher := sha256.New()
+		out := map[string]any{}
+		err := fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			name := entry.Name()
+			if filePath != "." && name[0] == '.' {
+				if entry.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if entry.IsDir() || (!file.IsYaml(name) && !file.IsJson(name)) {
+				return nil
+			}
+			content, err := fs.ReadFile(fsys, filePath)
+			if err != nil {
+				return fmt.Errorf("failed to read file %s: %w", filePath, err)
+			}
+			hasher.Write([]byte(filePath))
+			hasher.Write(content)
+			value, err := decode(content)
+			if err != nil {
+				return fmt.Errorf("failed to decode file %s: %w", filePath, err)
+			}
+			keys := strings.Split(strings.TrimSuffix(filePath, path.Ext(filePath)), "/")
+			merge(out, nest(keys, value))
+			return nil
+		})
+		var hash [32]byte
+		copy(hash[:], hasher.Sum(nil))
+		return out, hash, err
+	}
+}
+
+// FromConfigMaps converts ConfigMaps to documents, each ConfigMap is exposed under its name.
+// Keys with a yaml or json extension are decoded (and exposed without the extension), other keys are exposed as strings.
+func FromConfigMaps(configMaps ...*corev1.ConfigMap) (map[string]any, [32]byte, error) {
+	hasher := sha256.New()
+	out := map[string]any{}
+	slices.SortFunc(configMaps, func(a, b *corev1.ConfigMap) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+	for _, configMap := range configMaps {
+		hasher.Write([]byte(configMap.Namespace + "/" + configMap.Name + "@" + configMap.ResourceVersion))
+		documents := map[string]any{}
+		for key, content := range configMap.Data {
+			if !file.IsYaml(key) && !file.IsJson(key) {
+				documents[key] = content
+				continue
+			}
+			value, err := decode([]byte(content))
+			if err != nil {
+				var hash [32]byte
+				return nil, hash, fmt.Errorf("failed to decode key %s of configmap %s/%s: %w", key, configMap.Namespace, configMap.Name, err)
+			}
+			documents[strings.TrimSuffix(key, path.Ext(key))] = value
+		}
+		merge(out, map[string]any{configMap.Name: documents})
+	}
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	return out, hash, nil
+}
+
+// Watch loads documents into the store layer and reloads them at the given interval.
+// The first load must succeed, subsequent failures keep the previous documents in place.
+func Watch(store *Store, layer string, load LoadFunc, interval time.Duration) (func(context.Context), error) {
+	documents, hash, err := load(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data documents from %s: %w", layer, err)
+	}
+	store.Store(layer, documents)
+	return func(ctx context.Context) {
+		logger := ctrl.LoggerFrom(ctx).WithValues("documents", layer)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				documents, next, err := load(ctx)
+				if err != nil {
+					logger.Error(err, "failed to reload data documents")
+					continue
+				}
+				if next == hash {
+					continue
+				}
+				hash = next
+				store.Store(layer, documents)
+				logger.Info("data documents reloaded")
+			}
+		}
+	}, nil
+}
+
+// WatchAll loads documents from the given urls, urls are resolved using lookup.
+// It returns the functions reloading the documents, they should be run until the context is cancelled.
+func WatchAll(store *Store, lookup func(string) (fs.FS, error), interval time.Duration, urls ...string) ([]func(context.Context), error) {
+	var watchers []func(context.Context)
+	for _, url := range urls {
+		fsys, err := lookup(url)
+		if err != nil {
+			return nil, err
+		}
+		watcher, err := Watch(store, url, FromFS(fsys), interval)
+		if err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, watcher)
+	}
+	return watchers, nil
+}
+
+// decode parses a yaml (or json) content, multiple documents are returned as a list.
+func decode(content []byte) (any, error) {
+	documents, err := yaml.SplitDocuments(content)
+	if err != nil {
+		return nil, err
+	}
+	var values []any
+	for _, document := range documents {
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+		data, err := k8syaml.YAMLToJSON(document)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		values = append(values, normalize(value))
+	}
+	switch len(values) {
+	case 0:
+		return nil, nil
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
+
+// normalize converts json numbers to int64 when possible or float64 otherwise
+// so that CEL arithmetic on integers behaves as expected.
+func normalize(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for k, v := range value {
+			value[k] = normalize(v)
+		}
+		return value
+	case []any:
+		for i, v := range value {
+			value[i] = normalize(v)
+		}
+		return value
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	default:
+		return value
+	}
+}
+
+func nest(keys []string, value any) map[string]any {
+	out := map[string]any{keys[len(keys)-1]: value}
+	for i := len(keys) - 2; i >= 0; i-- {
+		out = map[string]any{keys[i]: out}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package documents
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"roles.yaml":          {Data: []byte("admin:\n- read\n- write\nviewer:\n- read\n")},
+		"tenants/acme.json":   {Data: []byte(`{"quota": 10, "ratio": 0.5}`)},
+		"tenants/globex.yaml": {Data: []byte("quota: 20\n---\nquota: 30\n")},
+		"README.md":           {Data: []byte("ignored")},
+		"..data/roles.yaml":   {Data: []byte("stale: true\n")},
+	}
+	documents, hash, err := FromFS(fsys)(context.Background())
+	assert.NoError(t, err)
+	assert.NotEqual(t, [32]byte{}, hash)
+	assert.Equal(t, map[string]any{
+		"roles": map[string]any{
+			"admin":  []any{"read", "write"},
+			"viewer": []any{"read"},
+		},
+		"tenants": map[string]any{
+			"acme": map[string]any{
+				"quota": int64(10),
+				"ratio": 0.5,
+			},
+			"globex": []any{
+				map[string]any{"quota": int64(20)},
+				map[string]any{"quota": int64(30)},
+			},
+		},
+	}, documents)
+	_, same, err := FromFS(fsys)(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, hash, same)
+}
+
+func TestFromFS_Invalid(t *testing.T) {
+	_, _, err := FromFS(fstest.MapFS{"bad.json": {Data: []byte(`{"foo":`)}})(context.Background())
+	assert.ErrorContains(t, err, "bad.json")
+}
+
+func TestFromConfigMaps(t *testing.T) {
+	documents, _, err := FromConfigMaps(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenants", Namespace: "default"},
+		Data: map[string]string{
+			"acme.yaml": "quota: 10\n",
+			"owner":     "platform-team",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"tenants": map[string]any{
+			"acme":  map[string]any{"quota": int64(10)},
+			"owner": "platform-team",
+		},
+	}, documents)
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	assert.Empty(t, store.Get())
+	store.Store("b", map[string]any{"tenants": map[string]any{"acme": 2, "globex": 3}})
+	store.Store("a", map[string]any{"tenants": map[string]any{"acme": 1, "initech": 4}, "roles": "x"})
+	assert.Equal(t, map[string]any{
+		"tenants": map[string]any{"acme": 2, "globex": 3, "initech": 4},
+		"roles":   "x",
+	}, store.Get())
+	// layers are not modified by merging
+	store.Store("b", map[string]any{})
+	assert.Equal(t, map[string]any{
+		"tenants": map[string]any{"acme": 1, "initech": 4},
+		"roles":   "x",
+	}, store.Get())
+}
+
+func TestWatch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.yaml": {Data: []byte("enabled: false\n")},
+	}
+	store := NewStore()
+	run, err := Watch(store, "test", FromFS(fsys), 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"config": map[string]any{"enabled": false}}, store.Get())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fsys["config.yaml"] = &fstest.MapFile{Data: []byte("enabled: true\n")}
+	go run(ctx)
+	assert.Eventually(t, func() bool {
+		return store.Get()["config"].(map[string]any)["enabled"] == true
+	}, time.Second, 10*time.Millisecond)
+}
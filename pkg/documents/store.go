@@ -0,0 +1,69 @@
+package documents
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Store holds the data documents exposed to policies through the `data` variable.
+// Documents are grouped in layers (one per source), layers are merged in name order.
+// The generation is incremented every time the documents change.
+type Store struct {
+	lock       sync.RWMutex
+	layers     map[string]map[string]any
+	merged     map[string]any
+	generation int64
+}
+
+func NewStore() *Store {
+	return &Store{
+		layers: map[string]map[string]any{},
+		merged: map[string]any{},
+	}
+}
+
+var Default = sync.OnceValue(NewStore)
+
+// Store replaces the documents of the given layer.
+func (s *Store) Store(layer string, documents map[string]any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.layers[layer] = documents
+	merged := map[string]any{}
+	for _, name := range slices.Sorted(maps.Keys(s.layers)) {
+		merge(merged, s.layers[name])
+	}
+	s.merged = merged
+	s.generation++
+}
+
+// Get returns a snapshot of the merged documents, the returned map must not be modified.
+func (s *Store) Get() map[string]any {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.merged
+}
+
+// Generation returns a counter incremented every time the documents change.
+func (s *Store) Generation() int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.generation
+}
+
+// merge deep merges src into dst, maps are merged recursively and other values are replaced.
+// Maps coming from src are copied before being modified so that layers are never altered.
+func merge(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcOk := value.(map[string]any)
+		dstMap, dstOk := dst[key].(map[string]any)
+		if srcOk && dstOk {
+			copied := maps.Clone(dstMap)
+			merge(copied, srcMap)
+			dst[key] = copied
+		} else {
+			dst[key] = value
+		}
+	}
+}
@@ -8,21 +8,27 @@ import (
 	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
 	authzcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel"
 	envoy "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/envoy"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
 	httpauth "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
 	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
-	"github.com/kyverno/kyverno/pkg/cel/libs/http"
-	"github.com/kyverno/kyverno/pkg/cel/libs/imagedata"
-	"github.com/kyverno/kyverno/pkg/cel/libs/resource"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/dynamic"
 )
 
 const (
+	DataKey      = "data"
 	HttpKey      = "http"
 	ImageDataKey = "image"
+	JsonKey      = "json"
+	JwksKey      = "jwks"
+	JwtKey       = "jwt"
+	LibKey       = "lib"
 	ObjectKey    = "object"
+	RateLimitKey = "ratelimit"
+	StreamKey    = "stream"
 	VariablesKey = "variables"
 	ResourceKey  = "resource"
 )
@@ -34,62 +40,53 @@ func NewCompiler[DATA dynamic.Interface, IN, OUT any]() *compiler[DATA, IN, OUT]
 type compiler[DATA dynamic.Interface, IN, OUT any] struct{}
 
 func (c *compiler[DATA, IN, OUT]) Compile(policy *vpol.ValidatingPolicy) (policy.Policy[DATA, IN, OUT], field.ErrorList) {
-	matchConditions, variables, rules, err := c.compiledEnvironment(policy)
+	matchConditions, variables, rules, references, err := c.compiledEnvironment(policy)
 	if err != nil {
 		return compiledPolicy[DATA, IN, OUT]{}, err
 	}
-	return compiledPolicy[DATA, IN, OUT]{
+	// policies are pointers so that they can be compared by identity (decision caches detect changes this way)
+	return &compiledPolicy[DATA, IN, OUT]{
 		failurePolicy:   policy.GetFailurePolicy(),
 		variables:       variables,
 		matchConditions: matchConditions,
 		rules:           rules,
+		references:      references,
 	}, err
 }
 
-func (c *compiler[DATA, IN, OUT]) compiledEnvironment(policy *vpol.ValidatingPolicy) ([]cel.Program, map[string]cel.Program, []cel.Program, field.ErrorList) {
+func (c *compiler[DATA, IN, OUT]) compiledEnvironment(policy *vpol.ValidatingPolicy) ([]cel.Program, map[string]cel.Program, []cel.Program, references, field.ErrorList) {
 	var allErrs field.ErrorList
-	base, err := authzcel.NewEnv(policy.Spec.EvaluationMode())
+	shared, generation, err := sharedEnvironments.get(policy.Spec.EvaluationMode())
 	if err != nil {
-		return nil, nil, nil, append(allErrs, field.InternalError(nil, err))
+		return nil, nil, nil, references{}, append(allErrs, field.InternalError(nil, err))
 	}
-	var objectKey cel.EnvOption
-	switch policy.Spec.EvaluationMode() {
-	case v1alpha1.EvaluationModeEnvoy:
-		objectKey = cel.Variable(ObjectKey, envoy.CheckRequest)
-	case v1alpha1.EvaluationModeHTTP:
-		objectKey = cel.Variable(ObjectKey, httpauth.RequestType)
-	default:
-		return nil, nil, nil, append(allErrs, field.InternalError(nil, fmt.Errorf("invalid policy evaluation mode: %s", policy.Spec.EvaluationMode())))
-	}
-	provider := authzcel.NewVariablesProvider(base.CELTypeProvider())
-	env, err := base.Extend(
-		cel.Variable(HttpKey, http.ContextType),
-		cel.Variable(ImageDataKey, imagedata.ContextType),
-		objectKey,
-		cel.Variable(VariablesKey, authzcel.VariablesType),
-		cel.Variable(ResourceKey, resource.ContextType),
+	// the variables provider is specific to the policy, the base environment is shared
+	provider := authzcel.NewVariablesProvider(shared.env.CELTypeProvider())
+	env, err := shared.env.Extend(
 		cel.CustomTypeProvider(provider),
 	)
 	if err != nil {
-		return nil, nil, nil, append(allErrs, field.InternalError(nil, err))
+		return nil, nil, nil, references{}, append(allErrs, field.InternalError(nil, err))
+	}
+	scope := &programScope{
+		evalMode:   string(policy.Spec.EvaluationMode()),
+		generation: generation,
 	}
+	refs := newReferences(shared.functions)
 	path := field.NewPath("spec")
 	matchConditions := make([]cel.Program, 0, len(policy.Spec.MatchConditions))
 	{
 		path := path.Child("matchConditions")
 		for i, matchCondition := range policy.Spec.MatchConditions {
 			path := path.Index(i).Child("expression")
-			ast, issues := env.Compile(matchCondition.Expression)
-			if err := issues.Err(); err != nil {
-				return nil, nil, nil, append(allErrs, field.Invalid(path, matchCondition.Expression, err.Error()))
+			ast, prog, err := sharedPrograms.compile(env, scope, matchCondition.Expression)
+			if err != nil {
+				return nil, nil, nil, references{}, append(allErrs, field.Invalid(path, matchCondition.Expression, err.Error()))
 			}
 			if !ast.OutputType().IsExactType(types.BoolType) {
-				return nil, nil, nil, append(allErrs, field.Invalid(path, matchCondition.Expression, "matchCondition output is expected to be of type bool"))
-			}
-			prog, err := env.Program(ast)
-			if err != nil {
-				return nil, nil, nil, append(allErrs, field.Invalid(path, matchCondition.Expression, err.Error()))
+				return nil, nil, nil, references{}, append(allErrs, field.Invalid(path, matchCondition.Expression, "matchCondition output is expected to be of type bool"))
 			}
+			refs.add(ast)
 			matchConditions = append(matchConditions, prog)
 		}
 	}
@@ -98,15 +95,13 @@ func (c *compiler[DATA, IN, OUT]) compiledEnvironment(policy *vpol.ValidatingPol
 		path := path.Child("variables")
 		for i, variable := range policy.Spec.Variables {
 			path := path.Index(i).Child("expression")
-			ast, issues := env.Compile(variable.Expression)
-			if err := issues.Err(); err != nil {
-				return nil, nil, nil, append(allErrs, field.Invalid(path, variable.Expression, err.Error()))
-			}
-			provider.RegisterField(variable.Name, ast.OutputType())
-			prog, err := env.Program(ast)
+			ast, prog, err := sharedPrograms.compile(env, scope, variable.Expression)
 			if err != nil {
-				return nil, nil, nil, append(allErrs, field.Invalid(path, variable.Expression, err.Error()))
+				return nil, nil, nil, references{}, append(allErrs, field.Invalid(path, variable.Expression, err.Error()))
 			}
+			provider.RegisterField(variable.Name, ast.OutputType())
+			scope.declare(variable.Name, ast.OutputType())
+			refs.add(ast)
 			variables[variable.Name] = prog
 		}
 	}
@@ -115,22 +110,22 @@ func (c *compiler[DATA, IN, OUT]) compiledEnvironment(policy *vpol.ValidatingPol
 		path := path.Child("validations")
 		for i, rule := range policy.Spec.Validations {
 			path := path.Index(i)
-			program, errs := c.compileAuthorization(path, policy.Spec.EvaluationMode(), rule, env)
+			program, errs := c.compileAuthorization(path, policy.Spec.EvaluationMode(), rule, env, scope, refs)
 			if errs != nil {
-				return nil, nil, nil, append(allErrs, errs...)
+				return nil, nil, nil, references{}, append(allErrs, errs...)
 			}
 			rules = append(rules, program)
 		}
 	}
-	return matchConditions, variables, rules, nil
+	return matchConditions, variables, rules, refs, nil
 }
 
-func (c *compiler[DATA, IN, OUT]) compileAuthorization(path *field.Path, evalMode vpol.EvaluationMode, rule admissionregistrationv1.Validation, env *cel.Env) (cel.Program, field.ErrorList) {
+func (c *compiler[DATA, IN, OUT]) compileAuthorization(path *field.Path, evalMode vpol.EvaluationMode, rule admissionregistrationv1.Validation, env *cel.Env, scope *programScope, refs references) (cel.Program, field.ErrorList) {
 	var allErrs field.ErrorList
 	{
 		path := path.Child("expression")
-		ast, issues := env.Compile(rule.Expression)
-		if err := issues.Err(); err != nil {
+		ast, prog, err := sharedPrograms.compile(env, scope, rule.Expression)
+		if err != nil {
 			return nil, append(allErrs, field.Invalid(path, rule.Expression, err.Error()))
 		}
 		switch evalMode {
@@ -139,16 +134,57 @@ func (c *compiler[DATA, IN, OUT]) compileAuthorization(path *field.Path, evalMod
 				msg := fmt.Sprintf("rule response output is expected to be of type %s", envoy.CheckResponse.TypeName())
 				return nil, append(allErrs, field.Invalid(path, rule.Expression, msg))
 			}
+		case v1alpha1.EvaluationModeExtProc:
+			if !ast.OutputType().IsExactType(extproc.ProcessingResponse) && !ast.OutputType().IsExactType(types.NullType) {
+				msg := fmt.Sprintf("rule response output is expected to be of type %s", extproc.ProcessingResponse.TypeName())
+				return nil, append(allErrs, field.Invalid(path, rule.Expression, msg))
+			}
 		case v1alpha1.EvaluationModeHTTP:
 			if !ast.OutputType().IsExactType(httpauth.ResponseType) && !ast.OutputType().IsExactType(types.NullType) {
 				msg := fmt.Sprintf("rule response output is expected to be of type %s", httpauth.ResponseType.TypeName())
 				return nil, append(allErrs, field.Invalid(path, rule.Expression, msg))
 			}
+		case v1alpha1.EvaluationModeSubjectAccessReview:
+			if !ast.OutputType().IsExactType(sar.ResponseType) && !ast.OutputType().IsExactType(types.NullType) {
+				msg := fmt.Sprintf("rule response output is expected to be of type %s", sar.ResponseType.TypeName())
+				return nil, append(allErrs, field.Invalid(path, rule.Expression, msg))
+			}
 		}
-		prog, err := env.Program(ast)
-		if err != nil {
-			return nil, append(allErrs, field.Invalid(path, rule.Expression, err.Error()))
-		}
+		refs.add(ast)
 		return prog, nil
 	}
 }
+
+// references is the set of variables referenced by the expressions of a policy, including the variables
+// referenced by the shared functions they call. Expensive contexts are only bound when a policy references them.
+type references struct {
+	names map[string]struct{}
+	// functions holds the variables referenced by shared functions, by overload id
+	functions map[string][]string
+}
+
+func newReferences(functions map[string][]string) references {
+	return references{
+		names:     map[string]struct{}{},
+		functions: functions,
+	}
+}
+
+func (r references) add(ast *cel.Ast) {
+	for _, ref := range ast.NativeRep().ReferenceMap() {
+		// functions have overloads, variables don't
+		if len(ref.OverloadIDs) == 0 && ref.Name != "" {
+			r.names[ref.Name] = struct{}{}
+		}
+		for _, id := range ref.OverloadIDs {
+			for _, name := range r.functions[id] {
+				r.names[name] = struct{}{}
+			}
+		}
+	}
+}
+
+func (r references) has(name string) bool {
+	_, ok := r.names[name]
+	return ok
+}
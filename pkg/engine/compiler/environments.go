@@ -0,0 +1,92 @@
+package compiler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	authzcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel"
+	envoy "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/envoy"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
+	httpauth "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+	"github.com/kyverno/kyverno/pkg/cel/libs/http"
+	"github.com/kyverno/kyverno/pkg/cel/libs/imagedata"
+	"github.com/kyverno/kyverno/pkg/cel/libs/resource"
+)
+
+// environments caches the base environment of each evaluation mode.
+// Environments are immutable and shared by all policies, they are rebuilt when shared functions change.
+type environments struct {
+	lock       sync.Mutex
+	generation int64
+	envs       map[vpol.EvaluationMode]*environment
+}
+
+// environment is a base environment and the variables referenced by the shared functions it declares.
+type environment struct {
+	env *cel.Env
+	// functions holds the variables referenced by shared functions, by overload id
+	functions map[string][]string
+}
+
+var sharedEnvironments = &environments{
+	envs: map[vpol.EvaluationMode]*environment{},
+}
+
+// get returns the base environment of the given evaluation mode and the shared functions generation it was built with.
+func (e *environments) get(evalMode vpol.EvaluationMode) (*environment, int64, error) {
+	// read the generation before building the env, a concurrent change makes the next call rebuild it
+	generation := functions.Default().Generation()
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if generation != e.generation {
+		clear(e.envs)
+		e.generation = generation
+	}
+	if env, ok := e.envs[evalMode]; ok {
+		return env, generation, nil
+	}
+	env, err := newEnv(evalMode)
+	if err != nil {
+		return nil, 0, err
+	}
+	shared := &environment{
+		env:       env,
+		functions: functions.References(env, functions.Default().Functions()...),
+	}
+	e.envs[evalMode] = shared
+	return shared, generation, nil
+}
+
+func newEnv(evalMode vpol.EvaluationMode) (*cel.Env, error) {
+	base, err := authzcel.NewEnv(evalMode)
+	if err != nil {
+		return nil, err
+	}
+	var objectKey cel.EnvOption
+	switch evalMode {
+	case v1alpha1.EvaluationModeEnvoy:
+		objectKey = cel.Variable(ObjectKey, envoy.CheckRequest)
+	case v1alpha1.EvaluationModeExtProc:
+		objectKey = cel.Variable(ObjectKey, extproc.ProcessingRequest)
+	case v1alpha1.EvaluationModeHTTP:
+		objectKey = cel.Variable(ObjectKey, httpauth.RequestType)
+	case v1alpha1.EvaluationModeSubjectAccessReview:
+		objectKey = cel.Variable(ObjectKey, sar.RequestType)
+	default:
+		return nil, fmt.Errorf("invalid policy evaluation mode: %s", evalMode)
+	}
+	return base.Extend(
+		cel.Variable(DataKey, types.NewMapType(types.StringType, types.DynType)),
+		cel.Variable(HttpKey, http.ContextType),
+		cel.Variable(ImageDataKey, imagedata.ContextType),
+		objectKey,
+		cel.Variable(VariablesKey, authzcel.VariablesType),
+		cel.Variable(ResourceKey, resource.ContextType),
+	)
+}
@@ -3,15 +3,23 @@ package compiler
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
 	authzcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/impl"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/extproc"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
+	jsoncel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/json"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/jwk"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/jwt"
+	ratelimitcel "github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/ratelimit"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/utils"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/documents"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/variables"
-	"github.com/kyverno/kyverno/pkg/cel/libs/http"
-	"github.com/kyverno/kyverno/pkg/cel/libs/imagedata"
 	"github.com/kyverno/kyverno/pkg/cel/libs/resource"
 	"go.uber.org/multierr"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
@@ -24,21 +32,35 @@ type compiledPolicy[DATA dynamic.Interface, IN, OUT any] struct {
 	matchConditions []cel.Program
 	variables       map[string]cel.Program
 	rules           []cel.Program
+	references      references
 }
 
 func (p compiledPolicy[DATA, IN, OUT]) Evaluate(ctx context.Context, dynclient DATA, r IN) (OUT, error) {
 	var zero OUT // create a zero variable of the output type
-	response, err := p.evaluateRules(r, dynclient)
+	// library calls are memoized across the policies evaluated for the same request
+	m := memo.FromContext(ctx)
+	if m == nil {
+		m = memo.New()
+	}
+	// ext_proc policies share the state of the stream the request belongs to
+	response, err := p.evaluateRules(r, dynclient, receivers{memo: m, stream: extproc.FromContext(ctx)})
 	if err != nil && p.failurePolicy == admissionregistrationv1.Fail {
 		return zero, err
 	}
 	return response, nil
 }
 
-func (p compiledPolicy[DATA, IN, OUT]) match(r IN) (bool, error) {
+// Stateful returns true when evaluating the policy updates some state (rate limits), its decisions can't be cached.
+func (p compiledPolicy[DATA, IN, OUT]) Stateful() bool {
+	return p.references.has(RateLimitKey)
+}
+
+func (p compiledPolicy[DATA, IN, OUT]) match(r IN, rcv receivers) (bool, error) {
 	data := map[string]any{
+		DataKey:   documents.Default().Get(),
 		ObjectKey: r,
 	}
+	memoize(data, rcv)
 	var errs []error
 	for _, matchCondition := range p.matchConditions {
 		// evaluate the condition
@@ -63,19 +85,24 @@ func (p compiledPolicy[DATA, IN, OUT]) match(r IN) (bool, error) {
 	return true, multierr.Combine(errs...)
 }
 
-func (p compiledPolicy[DATA, IN, OUT]) setupVariables(r IN, d DATA) (map[string]any, error) {
-	loader, err := variables.ImageData(nil)
-	if err != nil {
-		return nil, err
-	}
+func (p compiledPolicy[DATA, IN, OUT]) setupVariables(r IN, d DATA, rcv receivers) map[string]any {
 	vars := lazy.NewMapValue(authzcel.VariablesType)
 	data := map[string]any{
-		HttpKey:      http.Context{ContextInterface: http.NewHTTP(nil)},
-		ImageDataKey: imagedata.Context{ContextInterface: loader},
+		DataKey:      documents.Default().Get(),
 		ObjectKey:    r,
-		ResourceKey:  resource.Context{ContextInterface: variables.NewResourceProvider(d)},
 		VariablesKey: vars,
 	}
+	// contexts are only bound when the policy references them
+	if p.references.has(HttpKey) {
+		data[HttpKey] = variables.Default().HTTP()
+	}
+	if p.references.has(ImageDataKey) {
+		data[ImageDataKey] = variables.Default().ImageData()
+	}
+	if p.references.has(ResourceKey) {
+		data[ResourceKey] = resource.Context{ContextInterface: variables.NewResourceProvider(d)}
+	}
+	memoize(data, rcv)
 	for name, variable := range p.variables {
 		vars.Append(name, func(*lazy.MapValue) ref.Val {
 			out, _, err := variable.Eval(data)
@@ -88,20 +115,17 @@ func (p compiledPolicy[DATA, IN, OUT]) setupVariables(r IN, d DATA) (map[string]
 			return nil
 		})
 	}
-	return data, nil
+	return data
 }
 
-func (p compiledPolicy[DATA, IN, OUT]) evaluateRules(r IN, dynclient DATA) (OUT, error) {
+func (p compiledPolicy[DATA, IN, OUT]) evaluateRules(r IN, dynclient DATA, rcv receivers) (OUT, error) {
 	var zero OUT // create a zero variable of the output type
-	if match, err := p.match(r); err != nil {
+	if match, err := p.match(r, rcv); err != nil {
 		return zero, err
 	} else if !match {
 		return zero, nil
 	}
-	data, err := p.setupVariables(r, dynclient)
-	if err != nil {
-		return zero, err
-	}
+	data := p.setupVariables(r, dynclient, rcv)
 	for _, rule := range p.rules {
 		// evaluate the rule
 		response, err := evaluateRule(rule, data)
@@ -121,6 +145,27 @@ func (p compiledPolicy[DATA, IN, OUT]) evaluateRules(r IN, dynclient DATA) (OUT,
 	return zero, nil
 }
 
+// receivers holds the per request state bound to the library receivers.
+type receivers struct {
+	memo   *memo.Memo
+	stream *extproc.State
+}
+
+// memoize overrides the receivers of the memoized libraries with receivers using the request memo,
+// and the stream receiver with the state of the request stream.
+// Shared functions bodies are evaluated with the same receivers.
+func memoize(data map[string]any, rcv receivers) {
+	bound := map[string]any{
+		JsonKey:      jsoncel.Json{JsonImpl: &impl.JsonImpl{}, Memo: rcv.memo},
+		JwksKey:      jwk.Jwks{Memo: rcv.memo},
+		JwtKey:       jwt.Jwt{Memo: rcv.memo},
+		RateLimitKey: ratelimitcel.RateLimit{RateLimitImpl: &impl.RateLimitImpl{}, Memo: rcv.memo},
+		StreamKey:    extproc.Stream{State: rcv.stream},
+	}
+	maps.Copy(data, bound)
+	data[LibKey] = functions.NewLibrary(bound)
+}
+
 func evaluateRule(rule cel.Program, data map[string]any) (any, error) {
 	out, _, err := rule.Eval(data)
 	// check error
@@ -0,0 +1,64 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/utils/lru"
+)
+
+// programCacheSize is the maximum number of compiled programs kept in the cache.
+const programCacheSize = 4096
+
+type compiledProgram struct {
+	ast     *cel.Ast
+	program cel.Program
+}
+
+// programs caches compiled programs keyed by a content hash, identical expressions compiled
+// in the same scope (see programScope) are compiled once across policies and policy versions.
+type programs struct {
+	cache *lru.Cache
+}
+
+var sharedPrograms = &programs{
+	cache: lru.New(programCacheSize),
+}
+
+// programScope identifies everything an expression is checked against besides the expression itself:
+// the evaluation mode, the shared functions generation and the types of the variables declared so far.
+type programScope struct {
+	evalMode   string
+	generation int64
+	variables  []string
+}
+
+func (s *programScope) declare(name string, t *cel.Type) {
+	s.variables = append(s.variables, name+":"+t.String())
+}
+
+func (s *programScope) key(expression string) [sha256.Size]byte {
+	return sha256.Sum256(fmt.Appendf(nil, "%s\x00%d\x00%s\x00%s", s.evalMode, s.generation, strings.Join(s.variables, ","), expression))
+}
+
+// compile returns the checked ast and program of an expression, compiling it only if it is not in the cache.
+// Failed compilations are not cached.
+func (p *programs) compile(env *cel.Env, scope *programScope, expression string) (*cel.Ast, cel.Program, error) {
+	key := scope.key(expression)
+	if cached, ok := p.cache.Get(key); ok {
+		compiled := cached.(compiledProgram)
+		return compiled.ast, compiled.program, nil
+	}
+	ast, issues := env.Compile(expression)
+	if err := issues.Err(); err != nil {
+		return nil, nil, err
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.cache.Add(key, compiledProgram{ast: ast, program: program})
+	return ast, program, nil
+}
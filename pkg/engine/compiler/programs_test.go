@@ -0,0 +1,58 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/lru"
+)
+
+func TestSharedEnvironments(t *testing.T) {
+	envs := &environments{envs: map[vpol.EvaluationMode]*environment{}}
+	first, generation, err := envs.get(v1alpha1.EvaluationModeEnvoy)
+	assert.NoError(t, err)
+	second, _, err := envs.get(v1alpha1.EvaluationModeEnvoy)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	other, _, err := envs.get(v1alpha1.EvaluationModeHTTP)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, other)
+	// environments are rebuilt when shared functions change
+	functions.Default().Store(t.Name())
+	defer functions.Default().Delete(t.Name())
+	third, next, err := envs.get(v1alpha1.EvaluationModeEnvoy)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, third)
+	assert.NotEqual(t, generation, next)
+}
+
+func TestSharedPrograms(t *testing.T) {
+	envs := &environments{envs: map[vpol.EvaluationMode]*environment{}}
+	shared, generation, err := envs.get(v1alpha1.EvaluationModeEnvoy)
+	assert.NoError(t, err)
+	env := shared.env
+	programs := &programs{cache: lru.New(programCacheSize)}
+	scope := &programScope{evalMode: string(v1alpha1.EvaluationModeEnvoy), generation: generation}
+	_, first, err := programs.compile(env, scope, `object.attributes.request.http.method == "GET"`)
+	assert.NoError(t, err)
+	_, second, err := programs.compile(env, &programScope{evalMode: scope.evalMode, generation: generation}, `object.attributes.request.http.method == "GET"`)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	// different expressions
+	_, other, err := programs.compile(env, scope, `object.attributes.request.http.method == "POST"`)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, other)
+	// same expression with different variable types
+	scope.declare("foo", cel.StringType)
+	_, declared, err := programs.compile(env, scope, `object.attributes.request.http.method == "GET"`)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, declared)
+	// failed compilations are not cached
+	_, _, err = programs.compile(env, scope, `object.unknown(`)
+	assert.Error(t, err)
+	assert.Equal(t, 3, programs.cache.Len())
+}
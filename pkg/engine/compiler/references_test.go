@@ -0,0 +1,117 @@
+package compiler
+
+import (
+	"context"
+	"testing"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/memo"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		variable   string
+		rule       string
+		referenced []string
+		missing    []string
+		stateful   bool
+	}{{
+		name:       "none",
+		variable:   `object.attributes.request.http.method`,
+		rule:       `envoy.Allowed().Response()`,
+		referenced: []string{ObjectKey},
+		missing:    []string{HttpKey, ImageDataKey, ResourceKey},
+	}, {
+		name:       "http",
+		variable:   `http.Get("https://example.com")`,
+		rule:       `envoy.Allowed().Response()`,
+		referenced: []string{HttpKey},
+		missing:    []string{ImageDataKey, ResourceKey},
+	}, {
+		name:       "image",
+		variable:   `object.attributes.request.http.method`,
+		rule:       `image.GetMetadata("ghcr.io/kyverno/kyverno:latest") != null ? envoy.Allowed().Response() : null`,
+		referenced: []string{ImageDataKey, ObjectKey},
+		missing:    []string{HttpKey, ResourceKey},
+	}, {
+		name:       "ratelimit",
+		variable:   `ratelimit.Allow("key", 10, 1)`,
+		rule:       `variables.value.allowed ? envoy.Allowed().Response() : envoy.Denied(429).Response()`,
+		referenced: []string{RateLimitKey},
+		missing:    []string{HttpKey, ImageDataKey, ResourceKey},
+		stateful:   true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pol := &vpol.ValidatingPolicy{
+				Spec: vpol.ValidatingPolicySpec{
+					EvaluationConfiguration: &vpol.EvaluationConfiguration{
+						Mode: v1alpha1.EvaluationModeEnvoy,
+					},
+					Variables: []admissionregistrationv1.Variable{{
+						Name:       "value",
+						Expression: tt.variable,
+					}},
+					Validations: []admissionregistrationv1.Validation{{
+						Expression: tt.rule,
+					}},
+				},
+			}
+			compiled, errs := NewCompiler[dynamic.Interface, *authv3.CheckRequest, *authv3.CheckResponse]().Compile(pol)
+			assert.NoError(t, errs.ToAggregate())
+			refs := compiled.(*compiledPolicy[dynamic.Interface, *authv3.CheckRequest, *authv3.CheckResponse]).references
+			for _, name := range tt.referenced {
+				assert.True(t, refs.has(name), name)
+			}
+			for _, name := range tt.missing {
+				assert.False(t, refs.has(name), name)
+			}
+			assert.Equal(t, tt.stateful, compiled.(interface{ Stateful() bool }).Stateful())
+		})
+	}
+}
+
+func TestLibraryReferences(t *testing.T) {
+	functions.Default().Store(t.Name(), v1alpha1.Function{
+		Name:       "limited",
+		Parameters: []v1alpha1.FunctionParameter{{Name: "key", Type: "string"}},
+		Expression: `!ratelimit.Allow(key, 1, 1).allowed`,
+	})
+	defer functions.Default().Delete(t.Name())
+	pol := &vpol.ValidatingPolicy{
+		Spec: vpol.ValidatingPolicySpec{
+			EvaluationConfiguration: &vpol.EvaluationConfiguration{
+				Mode: v1alpha1.EvaluationModeEnvoy,
+			},
+			Variables: []admissionregistrationv1.Variable{{
+				Name:       "limited",
+				Expression: `lib.limited("` + t.Name() + `")`,
+			}},
+			Validations: []admissionregistrationv1.Validation{{
+				// the function is called twice for the same request
+				Expression: `variables.limited || lib.limited("` + t.Name() + `") ? envoy.Denied(429).Response() : envoy.Allowed().Response()`,
+			}},
+		},
+	}
+	compiled, errs := NewCompiler[dynamic.Interface, *authv3.CheckRequest, *authv3.CheckResponse]().Compile(pol)
+	assert.NoError(t, errs.ToAggregate())
+	refs := compiled.(*compiledPolicy[dynamic.Interface, *authv3.CheckRequest, *authv3.CheckResponse]).references
+	assert.True(t, refs.has(RateLimitKey))
+	// parameters are not references
+	assert.False(t, refs.has("key"))
+	assert.True(t, compiled.(interface{ Stateful() bool }).Stateful())
+	// the function body uses the request memo, a request consumes a single token
+	resp, err := compiled.Evaluate(memo.NewContext(context.Background()), nil, &authv3.CheckRequest{})
+	assert.NoError(t, err)
+	assert.IsType(t, &authv3.CheckResponse_OkResponse{}, resp.HttpResponse)
+	resp, err = compiled.Evaluate(memo.NewContext(context.Background()), nil, &authv3.CheckRequest{})
+	assert.NoError(t, err)
+	assert.IsType(t, &authv3.CheckResponse_DeniedResponse{}, resp.HttpResponse)
+}
@@ -2,10 +2,14 @@ package engine
 
 import (
 	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/http"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/authz/sar"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/extensions/policy"
 	"k8s.io/client-go/dynamic"
 )
 
 type EnvoyPolicy = policy.Policy[dynamic.Interface, *authv3.CheckRequest, *authv3.CheckResponse]
+type ExtProcPolicy = policy.Policy[dynamic.Interface, *extprocv3.ProcessingRequest, *extprocv3.ProcessingResponse]
 type HTTPPolicy = policy.Policy[dynamic.Interface, *http.CheckRequest, *http.CheckResponse]
+type SubjectAccessReviewPolicy = policy.Policy[dynamic.Interface, *sar.CheckRequest, *sar.CheckResponse]
@@ -1,8 +1,26 @@
 package engine
 
 import (
+	"sync/atomic"
+
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
 )
 
 type EnvoySource = core.Source[EnvoyPolicy]
+type ExtProcSource = core.Source[ExtProcPolicy]
 type HTTPSource = core.Source[HTTPPolicy]
+type SubjectAccessReviewSource = core.Source[SubjectAccessReviewPolicy]
+
+// generation is incremented every time the policies loaded by the sources change
+var generation atomic.Int64
+
+// Generation returns a counter incremented every time policies are compiled or removed by a source,
+// decision caches compare it to detect policy changes.
+func Generation() int64 {
+	return generation.Load()
+}
+
+// Changed records a change of the policies loaded by a source.
+func Changed() {
+	generation.Add(1)
+}
@@ -2,7 +2,9 @@ package sources
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/core/sources"
@@ -10,18 +12,19 @@ import (
 )
 
 func NewControlPlane[POLICY any](
+	listener *listener,
 	compiler engine.Compiler[POLICY],
 ) (core.Source[POLICY], error) {
-	listener := NewListener()
 	cache := sources.NewCache(
 		listener,
 		func(_ context.Context, in *v1alpha1.ValidatingPolicy) (string, error) {
-			return in.Name + in.ResourceVersion, nil
+			// recompile policies when shared functions change
+			return fmt.Sprintf("%s%s@%d", in.Name, in.ResourceVersion, functions.Default().Generation()), nil
 		},
 		func(_ context.Context, _ string, in *v1alpha1.ValidatingPolicy) (POLICY, error) {
-			policy, err := compiler.Compile(in)
+			policy, err := compile(compiler, in)
 			return policy, err.ToAggregate()
 		},
 	)
-	return cache, nil
+	return newCounted(cache), nil
 }
@@ -0,0 +1,69 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/documents"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+type kubeDocuments struct {
+	layer string
+	load  documents.LoadFunc
+	store *documents.Store
+	lock  sync.Mutex
+	hash  [32]byte
+}
+
+// NewKubeDocuments loads the data ConfigMaps of the namespace into the store layer and registers a controller
+// reloading them when they change, the manager cache is expected to only hold the data ConfigMaps.
+// The manager cache must be synced, the first load must succeed.
+func NewKubeDocuments(ctx context.Context, mgr ctrl.Manager, store *documents.Store, layer, namespace string) error {
+	r := &kubeDocuments{
+		layer: layer,
+		load:  documents.FromKube(mgr.GetClient(), namespace),
+		store: store,
+	}
+	if err := r.reload(ctx); err != nil {
+		return fmt.Errorf("failed to load data documents from %s: %w", layer, err)
+	}
+	options := controller.Options{
+		NeedLeaderElection: ptr.To(false),
+	}
+	return ctrl.
+		NewControllerManagedBy(mgr).
+		For(&corev1.ConfigMap{}).
+		Named("data-documents").
+		WithOptions(options).
+		Complete(r)
+}
+
+// Reconcile reloads every data ConfigMap, documents are exposed as a whole so a single change requires a full reload.
+// Invalid documents are logged and the previous documents are kept in place.
+func (r *kubeDocuments) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if err := r.reload(ctx); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to reload data documents", "documents", r.layer)
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *kubeDocuments) reload(ctx context.Context) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	documents, hash, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+	if hash == r.hash {
+		return nil
+	}
+	r.hash = hash
+	r.store.Store(r.layer, documents)
+	ctrl.LoggerFrom(ctx).Info("data documents reloaded", "documents", r.layer)
+	return nil
+}
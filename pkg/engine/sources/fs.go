@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"sync"
 
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/data"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
 	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
@@ -19,7 +20,8 @@ import (
 )
 
 var (
-	vpolGVK = vpol.SchemeGroupVersion.WithKind("ValidatingPolicy")
+	vpolGVK            = vpol.SchemeGroupVersion.WithKind("ValidatingPolicy")
+	functionLibraryGVK = v1alpha1.SchemeGroupVersion.WithKind("FunctionLibrary")
 )
 
 type document = []byte
@@ -37,7 +39,7 @@ func defaultLoader(_fs func() (fs.FS, error)) (loader.Loader, error) {
 
 var DefaultLoader = sync.OnceValues(func() (loader.Loader, error) { return defaultLoader(nil) })
 
-func NewFs[POLICY any](f fs.FS, compiler engine.Compiler[POLICY]) core.Source[POLICY] {
+func newFsDocuments(f fs.FS) core.Source[document] {
 	input := sources.NewFs(f, func(_ string, entry fs.DirEntry) bool {
 		if entry == nil {
 			return false
@@ -54,7 +56,27 @@ func NewFs[POLICY any](f fs.FS, compiler engine.Compiler[POLICY]) core.Source[PO
 			return getDocuments(context.Background(), f, entry.DirEntry)
 		},
 	)
-	flatten := sources.NewFlatten(transform)
+	return sources.NewFlatten(transform)
+}
+
+func NewFs[POLICY any](f fs.FS, compiler engine.Compiler[POLICY]) core.Source[POLICY] {
+	// TODO: sort by policy name
+	compile := sources.NewTransformErr(
+		NewFsPolicies(f),
+		func(p *vpol.ValidatingPolicy) (POLICY, error) {
+			c, errs := compile(compiler, p)
+			if len(errs) > 0 {
+				return c, fmt.Errorf("failed to compile ValidatingPolicy: %w", errs.ToAggregate())
+			}
+			return c, nil
+		},
+	)
+	return compile
+}
+
+// NewFsPolicies returns a source of the ValidatingPolicy documents found in f.
+func NewFsPolicies(f fs.FS) core.Source[*vpol.ValidatingPolicy] {
+	flatten := newFsDocuments(f)
 	load := sources.NewTransformErr(
 		flatten,
 		func(document document) (*vpol.ValidatingPolicy, error) {
@@ -76,24 +98,43 @@ func NewFs[POLICY any](f fs.FS, compiler engine.Compiler[POLICY]) core.Source[PO
 			}
 			return nil, nil
 		})
-	filter := sources.NewFilter(
+	return sources.NewFilter(
 		load,
 		func(p *vpol.ValidatingPolicy) bool {
 			return p != nil
 		},
 	)
-	// TODO: sort by policy name
-	compile := sources.NewTransformErr(
-		filter,
-		func(p *vpol.ValidatingPolicy) (POLICY, error) {
-			c, errs := compiler.Compile(p)
-			if len(errs) > 0 {
-				return c, fmt.Errorf("failed to compile ValidatingPolicy: %w", errs.ToAggregate())
+}
+
+// NewFsFunctionLibraries returns a source of the FunctionLibrary documents found in f.
+func NewFsFunctionLibraries(f fs.FS) core.Source[*v1alpha1.FunctionLibrary] {
+	load := sources.NewTransformErr(
+		newFsDocuments(f),
+		func(document document) (*v1alpha1.FunctionLibrary, error) {
+			ldr, err := DefaultLoader()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load CRDs: %w", err)
 			}
-			return c, nil
+			gvk, untyped, err := ldr.Load(document)
+			if err != nil {
+				return nil, err
+			}
+			switch gvk {
+			case functionLibraryGVK:
+				typed, err := convert.To[v1alpha1.FunctionLibrary](untyped)
+				if err != nil {
+					return nil, fmt.Errorf("failed to convert to FunctionLibrary: %w", err)
+				}
+				return typed, nil
+			}
+			return nil, nil
+		})
+	return sources.NewFilter(
+		load,
+		func(l *v1alpha1.FunctionLibrary) bool {
+			return l != nil
 		},
 	)
-	return compile
 }
 
 func getDocuments(_ context.Context, f fs.FS, entry fs.DirEntry) ([]document, error) {
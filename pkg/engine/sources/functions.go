@@ -0,0 +1,48 @@
+package sources
+
+import (
+	"context"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/cel/libs/functions"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+type functionLibraries struct {
+	client   client.Client
+	registry *functions.Registry
+}
+
+// NewKubeFunctionLibraries registers a controller keeping the registry in sync with the FunctionLibrary resources.
+func NewKubeFunctionLibraries(mgr ctrl.Manager, registry *functions.Registry) error {
+	options := controller.Options{
+		NeedLeaderElection: ptr.To(false),
+	}
+	return ctrl.
+		NewControllerManagedBy(mgr).
+		For(&v1alpha1.FunctionLibrary{}).
+		Named("function-libraries").
+		WithOptions(options).
+		Complete(&functionLibraries{
+			client:   mgr.GetClient(),
+			registry: registry,
+		})
+}
+
+func (r *functionLibraries) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var library v1alpha1.FunctionLibrary
+	err := r.client.Get(ctx, req.NamespacedName, &library)
+	if errors.IsNotFound(err) {
+		r.registry.Delete(req.Name)
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.registry.Store(req.Name, library.Spec.Functions...)
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,51 @@
+package sources_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kyverno/kyverno-envoy-plugin/apis/v1alpha1"
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/sources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFsFunctionLibraries(t *testing.T) {
+	fsys := fstest.MapFS{
+		"library.yaml": {Data: []byte(`
+apiVersion: authz.kyverno.io/v1alpha1
+kind: FunctionLibrary
+metadata:
+  name: common
+spec:
+  functions:
+  - name: bearerToken
+    parameters:
+    - name: request
+      type: envoy.service.auth.v3.CheckRequest
+    expression: request.attributes.request.http.headers[?"authorization"].orValue("").split(" ")[1]
+---
+apiVersion: policies.kyverno.io/v1alpha1
+kind: ValidatingPolicy
+metadata:
+  name: policy
+spec:
+  evaluation:
+    mode: Envoy
+  validations:
+  - expression: envoy.Allowed().Response()
+`)},
+	}
+	libraries, err := sources.NewFsFunctionLibraries(fsys).Load(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, libraries, 1)
+	assert.Equal(t, "common", libraries[0].Name)
+	assert.Equal(t, []v1alpha1.Function{{
+		Name: "bearerToken",
+		Parameters: []v1alpha1.FunctionParameter{{
+			Name: "request",
+			Type: "envoy.service.auth.v3.CheckRequest",
+		}},
+		Expression: `request.attributes.request.http.headers[?"authorization"].orValue("").split(" ")[1]`,
+	}}, libraries[0].Spec.Functions)
+}
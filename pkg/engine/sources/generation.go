@@ -0,0 +1,41 @@
+package sources
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	vpol "github.com/kyverno/kyverno/api/policies.kyverno.io/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// compile compiles a policy and records the change when it succeeds.
+func compile[POLICY any](compiler engine.Compiler[POLICY], policy *vpol.ValidatingPolicy) (POLICY, field.ErrorList) {
+	out, errs := compiler.Compile(policy)
+	if len(errs) == 0 {
+		engine.Changed()
+	}
+	return out, errs
+}
+
+// counted records a change when the number of policies returned by inner changes (policies were removed for example),
+// compiled sources reuse previously compiled policies and only record changes when compiling.
+type counted[POLICY any] struct {
+	inner core.Source[POLICY]
+	count atomic.Int64
+}
+
+func newCounted[POLICY any](inner core.Source[POLICY]) *counted[POLICY] {
+	c := &counted[POLICY]{inner: inner}
+	c.count.Store(-1)
+	return c
+}
+
+func (c *counted[POLICY]) Load(ctx context.Context) ([]POLICY, error) {
+	policies, err := c.inner.Load(ctx)
+	if count := int64(len(policies)); c.count.Swap(count) != count {
+		engine.Changed()
+	}
+	return policies, err
+}
@@ -14,6 +14,7 @@ import (
 type listener struct {
 	lock      *sync.Mutex
 	resources map[string]*v1alpha1.ValidatingPolicy
+	synced    bool
 }
 
 func NewListener() *listener {
@@ -27,15 +28,24 @@ func (p *listener) Process(req *protov1alpha1.ValidatingPolicy) {
 	if req.Delete {
 		p.lock.Lock()
 		defer p.lock.Unlock()
+		p.synced = true
 		delete(p.resources, req.Name)
 		return
 	}
 	vpol := controlplane.FromProto(req)
 	p.lock.Lock()
 	defer p.lock.Unlock()
+	p.synced = true
 	p.resources[req.Name] = vpol
 }
 
+// Synced returns true once the control plane has delivered at least one policy.
+func (p *listener) Synced() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.synced
+}
+
 func (r *listener) Load(ctx context.Context) ([]*v1alpha1.ValidatingPolicy, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
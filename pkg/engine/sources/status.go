@@ -0,0 +1,146 @@
+package sources
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+)
+
+// Status describes the state of a policy source.
+type Status struct {
+	// Name of the source
+	Name string `json:"name"`
+	// Synced is true when the source has caught up with its backend (cache synced, first policies received, ...)
+	Synced bool `json:"synced"`
+	// Loaded is true when the source has been loaded successfully at least once
+	Loaded bool `json:"loaded"`
+	// Policies is the number of policies returned by the last load
+	Policies int `json:"policies"`
+	// LastLoad is the time of the last load
+	LastLoad *time.Time `json:"lastLoad,omitempty"`
+	// LastError is the error returned by the last load, if any
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Ready returns true when the source is synced and has been loaded.
+func (s Status) Ready() bool {
+	return s.Synced && s.Loaded
+}
+
+// Reporter is implemented by sources reporting their status.
+type Reporter interface {
+	Status(context.Context) Status
+}
+
+type tracked[POLICY any] struct {
+	name   string
+	inner  core.Source[POLICY]
+	synced func() bool
+	lock   sync.Mutex
+	status Status
+}
+
+// NewTracked wraps inner and records the result of every load.
+// synced reports whether the underlying backend is synced, the source is considered synced when nil.
+// A load counts as successful if it didn't fail or returned some policies.
+func NewTracked[POLICY any](name string, inner core.Source[POLICY], synced func() bool) *tracked[POLICY] {
+	return &tracked[POLICY]{
+		name:   name,
+		inner:  inner,
+		synced: synced,
+		status: Status{Name: name},
+	}
+}
+
+func (t *tracked[POLICY]) Load(ctx context.Context) ([]POLICY, error) {
+	policies, err := t.inner.Load(ctx)
+	now := time.Now()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.status.Policies = len(policies)
+	t.status.LastLoad = &now
+	t.status.LastError = ""
+	if err != nil {
+		t.status.LastError = err.Error()
+	}
+	if err == nil || len(policies) > 0 {
+		t.status.Loaded = true
+	}
+	return policies, err
+}
+
+// Status loads the source to refresh and return its status.
+func (t *tracked[POLICY]) Status(ctx context.Context) Status {
+	synced := t.synced == nil || t.synced()
+	// don't load a source that isn't synced yet, it would report an incomplete view
+	if synced {
+		_, _ = t.Load(ctx)
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	status := t.status
+	status.Synced = synced
+	return status
+}
+
+// Registry holds the reporters of the sources used by a process.
+type Registry struct {
+	lock      sync.Mutex
+	reporters map[string]Reporter
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		reporters: map[string]Reporter{},
+	}
+}
+
+// Default returns the process wide registry.
+var Default = sync.OnceValue(NewRegistry)
+
+// Register adds a reporter to the registry, the returned function removes it.
+func (r *Registry) Register(name string, reporter Reporter) func() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.reporters[name] = reporter
+	return func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if r.reporters[name] == reporter {
+			delete(r.reporters, name)
+		}
+	}
+}
+
+// Statuses returns the status of all registered sources, sorted by name.
+func (r *Registry) Statuses(ctx context.Context) []Status {
+	r.lock.Lock()
+	reporters := make([]Reporter, 0, len(r.reporters))
+	for _, reporter := range r.reporters {
+		reporters = append(reporters, reporter)
+	}
+	r.lock.Unlock()
+	statuses := make([]Status, 0, len(reporters))
+	for _, reporter := range reporters {
+		statuses = append(statuses, reporter.Status(ctx))
+	}
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return statuses
+}
+
+// Ready returns true when all registered sources are ready.
+func (r *Registry) Ready(ctx context.Context) bool {
+	for _, status := range r.Statuses(ctx) {
+		if !status.Ready() {
+			return false
+		}
+	}
+	return true
+}
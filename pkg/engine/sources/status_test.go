@@ -0,0 +1,65 @@
+package sources_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/sources"
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracked(t *testing.T) {
+	var err error
+	var policies []int
+	inner := core.MakeSourceFunc(func(context.Context) ([]int, error) {
+		return policies, err
+	})
+	synced := false
+	tracked := sources.NewTracked("test", inner, func() bool { return synced })
+	// not synced, the source is not loaded
+	status := tracked.Status(context.Background())
+	assert.Equal(t, sources.Status{Name: "test"}, status)
+	assert.False(t, status.Ready())
+	// synced but failing
+	synced, err = true, errors.New("failed to compile")
+	status = tracked.Status(context.Background())
+	assert.True(t, status.Synced)
+	assert.False(t, status.Loaded)
+	assert.Equal(t, "failed to compile", status.LastError)
+	assert.NotNil(t, status.LastLoad)
+	assert.False(t, status.Ready())
+	// loaded
+	policies, err = []int{1, 2}, nil
+	status = tracked.Status(context.Background())
+	assert.True(t, status.Ready())
+	assert.Equal(t, 2, status.Policies)
+	assert.Empty(t, status.LastError)
+	// errors after a successful load are reported but don't affect readiness
+	err = errors.New("failed to load")
+	status = tracked.Status(context.Background())
+	assert.True(t, status.Ready())
+	assert.Equal(t, "failed to load", status.LastError)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := sources.NewRegistry()
+	assert.True(t, registry.Ready(context.Background()))
+	assert.Empty(t, registry.Statuses(context.Background()))
+	ready := sources.NewTracked("b", core.MakeSource(1), nil)
+	notReady := sources.NewTracked("a", core.MakeSource(1), func() bool { return false })
+	registry.Register("b", ready)
+	unregister := registry.Register("a", notReady)
+	statuses := registry.Statuses(context.Background())
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "a", statuses[0].Name)
+	assert.Equal(t, "b", statuses[1].Name)
+	assert.False(t, registry.Ready(context.Background()))
+	unregister()
+	assert.True(t, registry.Ready(context.Background()))
+	data, err := json.Marshal(sources.Status{Name: "a", Synced: true})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"a","synced":true,"loaded":false,"policies":0}`, string(data))
+}
@@ -0,0 +1,114 @@
+package variables
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	nethttp "net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kyverno/kyverno/pkg/cel/libs/http"
+	"github.com/kyverno/kyverno/pkg/cel/libs/imagedata"
+	"github.com/kyverno/kyverno/pkg/imageverification/imagedataloader"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// DefaultHTTPTimeout is the default timeout of requests made by policies with the http library.
+const DefaultHTTPTimeout = 10 * time.Second
+
+// Config configures the contexts shared by all policies.
+type Config struct {
+	// HTTPTimeout is the timeout of requests made with the http library (no timeout when zero)
+	HTTPTimeout time.Duration
+	// HTTPCAFile is a file containing CAs trusted by the http library in addition to the system CAs
+	HTTPCAFile string
+	// Secrets is used to load image pull secrets
+	Secrets v1.SecretInterface
+	// ImagePullSecrets are the secrets used to authenticate with registries when fetching image data
+	ImagePullSecrets []string
+	// AllowInsecureRegistry allows fetching image data from insecure registries
+	AllowInsecureRegistry bool
+}
+
+// Contexts holds the http and image data contexts, they are created once and shared by all policies.
+type Contexts struct {
+	lock      sync.RWMutex
+	http      http.ContextInterface
+	imageData imagedata.ContextInterface
+}
+
+// Default returns the process wide contexts, configured with the default configuration until Configure is called.
+var Default = sync.OnceValue(func() *Contexts {
+	contexts, err := NewContexts(Config{HTTPTimeout: DefaultHTTPTimeout})
+	if err != nil {
+		panic(err)
+	}
+	return contexts
+})
+
+func NewContexts(config Config) (*Contexts, error) {
+	var contexts Contexts
+	if err := contexts.Configure(config); err != nil {
+		return nil, err
+	}
+	return &contexts, nil
+}
+
+// Configure replaces the contexts with contexts created from the given configuration.
+func (c *Contexts) Configure(config Config) error {
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return err
+	}
+	loader, err := ImageData(config.Secrets, imagedataloader.WithPullSecret(config.ImagePullSecrets), imagedataloader.WithInsecure(config.AllowInsecureRegistry))
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.http = http.NewHTTP(client)
+	c.imageData = loader
+	return nil
+}
+
+// HTTP returns the http library context.
+func (c *Contexts) HTTP() http.Context {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return http.Context{ContextInterface: c.http}
+}
+
+// ImageData returns the image data library context.
+func (c *Contexts) ImageData() imagedata.Context {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return imagedata.Context{ContextInterface: c.imageData}
+}
+
+func newHTTPClient(config Config) (*nethttp.Client, error) {
+	// the default transport uses the standard proxy environment variables (HTTP_PROXY, HTTPS_PROXY and NO_PROXY)
+	transport := nethttp.DefaultTransport.(*nethttp.Transport).Clone()
+	if config.HTTPCAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(config.HTTPCAFile)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", config.HTTPCAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	return &nethttp.Client{
+		Timeout:   config.HTTPTimeout,
+		Transport: transport,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package variables
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContexts(t *testing.T) {
+	contexts, err := NewContexts(Config{HTTPTimeout: DefaultHTTPTimeout})
+	assert.NoError(t, err)
+	assert.NotNil(t, contexts.HTTP().ContextInterface)
+	assert.NotNil(t, contexts.ImageData().ContextInterface)
+}
+
+func TestNewContextsInvalidCAFile(t *testing.T) {
+	_, err := NewContexts(Config{HTTPCAFile: filepath.Join(t.TempDir(), "missing.crt")})
+	assert.Error(t, err)
+	file := filepath.Join(t.TempDir(), "ca.crt")
+	assert.NoError(t, os.WriteFile(file, []byte("not a certificate"), 0o600))
+	_, err = NewContexts(Config{HTTPCAFile: file})
+	assert.Error(t, err)
+}
+
+func TestNewContextsPullSecretsWithoutClient(t *testing.T) {
+	_, err := NewContexts(Config{ImagePullSecrets: []string{"regcred"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package ipset
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/kyverno/pkg/ext/file"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Load reads all the files in fsys and merges them into a single set.
+// Hidden files and directories (like the ..data directory of mounted ConfigMaps)
+// are skipped, yaml and json files are ignored so that sets can live next to policies.
+func Load(fsys fs.FS) (*Set, [32]byte, error) {
+	var content bytes.Buffer
+	err := fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if path != "." && name[0] == '.' {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || file.IsYaml(name) || file.IsJson(name) {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		content.Write(data)
+		content.WriteByte('\n')
+		return nil
+	})
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	hash := sha256.Sum256(content.Bytes())
+	set, err := Parse(&content)
+	if err != nil {
+		return nil, hash, err
+	}
+	return set, hash, nil
+}
+
+// Watch loads the set from fsys into the registry and reloads it at the given interval.
+// The first load must succeed, subsequent failures keep the previous set in place.
+func Watch(registry *Registry, name string, fsys fs.FS, interval time.Duration) (func(context.Context), error) {
+	set, hash, err := Load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ip set %s: %w", name, err)
+	}
+	registry.Store(name, set)
+	return func(ctx context.Context) {
+		logger := ctrl.LoggerFrom(ctx).WithValues("ipset", name)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				set, next, err := Load(fsys)
+				if err != nil {
+					logger.Error(err, "failed to reload ip set")
+					continue
+				}
+				if next == hash {
+					continue
+				}
+				hash = next
+				registry.Store(name, set)
+				logger.Info("ip set reloaded", "size", set.Len())
+			}
+		}
+	}, nil
+}
+
+// WatchAll loads the sets described by specs of the form name=url, urls are resolved using lookup.
+// It returns the functions reloading the sets, they should be run until the context is cancelled.
+func WatchAll(registry *Registry, lookup func(string) (fs.FS, error), interval time.Duration, specs ...string) ([]func(context.Context), error) {
+	var watchers []func(context.Context)
+	for _, spec := range specs {
+		name, url, ok := strings.Cut(spec, "=")
+		if !ok || name == "" || url == "" {
+			return nil, fmt.Errorf("invalid ip set %q, expected name=url", spec)
+		}
+		fsys, err := lookup(url)
+		if err != nil {
+			return nil, err
+		}
+		watcher, err := Watch(registry, name, fsys, interval)
+		if err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, watcher)
+	}
+	return watchers, nil
+}
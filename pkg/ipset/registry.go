@@ -0,0 +1,49 @@
+package ipset
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry holds named sets, sets can be replaced atomically while being used.
+// The generation is incremented every time a set is stored.
+type Registry struct {
+	lock       sync.RWMutex
+	sets       map[string]*Set
+	generation int64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		sets: map[string]*Set{},
+	}
+}
+
+// Default returns the process wide registry.
+var Default = sync.OnceValue(NewRegistry)
+
+// Store registers (or replaces) the set with the given name.
+func (r *Registry) Store(name string, set *Set) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sets[name] = set
+	r.generation++
+}
+
+// Generation returns a counter incremented every time a set is stored.
+func (r *Registry) Generation() int64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.generation
+}
+
+// Get returns the set with the given name.
+func (r *Registry) Get(name string) (*Set, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	set, ok := r.sets[name]
+	if !ok {
+		return nil, fmt.Errorf("ip set not found: %s", name)
+	}
+	return set, nil
+}
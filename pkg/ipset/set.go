@@ -0,0 +1,165 @@
+package ipset
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+)
+
+type node struct {
+	children [2]*node
+	terminal bool
+}
+
+// Set is an immutable set of IP prefixes stored in a binary radix tree.
+// Lookups run in O(address length) regardless of the number of prefixes.
+type Set struct {
+	v4   *node
+	v6   *node
+	size int
+}
+
+// New returns a set built from the given prefixes.
+func New(prefixes ...netip.Prefix) *Set {
+	s := &Set{
+		v4: &node{},
+		v6: &node{},
+	}
+	for _, prefix := range prefixes {
+		s.insert(prefix)
+	}
+	return s
+}
+
+// Parse reads a set from a list of IP addresses or CIDRs, one per line.
+// Empty lines and comments starting with # are ignored.
+func Parse(r io.Reader) (*Set, error) {
+	var prefixes []netip.Prefix
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		prefix, err := ParsePrefix(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return New(prefixes...), nil
+}
+
+// ParsePrefix parses a CIDR or a single IP address (as a full length prefix).
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Len returns the number of prefixes in the set, prefixes covered by a shorter prefix are not counted.
+func (s *Set) Len() int {
+	return s.size
+}
+
+// Contains returns true if the address belongs to at least one prefix of the set.
+func (s *Set) Contains(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	addr = addr.Unmap()
+	n := s.root(addr)
+	if n.terminal {
+		return true
+	}
+	bytes := addr.AsSlice()
+	for i := range addr.BitLen() {
+		n = n.children[bit(bytes, i)]
+		if n == nil {
+			return false
+		}
+		if n.terminal {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Set) insert(prefix netip.Prefix) {
+	if !prefix.IsValid() {
+		return
+	}
+	addr := prefix.Addr()
+	bits := prefix.Bits()
+	if addr.Is4In6() {
+		addr = addr.Unmap()
+		bits = max(bits-96, 0)
+	}
+	n := s.root(addr)
+	bytes := addr.AsSlice()
+	for i := range bits {
+		// a shorter prefix already covers this one
+		if n.terminal {
+			return
+		}
+		b := bit(bytes, i)
+		if n.children[b] == nil {
+			n.children[b] = &node{}
+		}
+		n = n.children[b]
+	}
+	if !n.terminal {
+		n.terminal = true
+		// longer prefixes are now redundant
+		s.size += 1 - n.terminals()
+		n.children = [2]*node{}
+	}
+}
+
+// terminals returns the number of prefixes stored below n.
+func (n *node) terminals() int {
+	count := 0
+	for _, child := range n.children {
+		if child == nil {
+			continue
+		}
+		if child.terminal {
+			// terminal nodes have no children
+			count++
+		} else {
+			count += child.terminals()
+		}
+	}
+	return count
+}
+
+func (s *Set) root(addr netip.Addr) *node {
+	if addr.Is4() {
+		return s.v4
+	}
+	return s.v6
+}
+
+func bit(bytes []byte, i int) int {
+	return int(bytes[i/8]>>(7-i%8)) & 1
+}
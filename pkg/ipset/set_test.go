@@ -0,0 +1,91 @@
+package ipset
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet_Contains(t *testing.T) {
+	set, err := Parse(strings.NewReader(`
+# comment
+10.0.0.0/8
+192.168.1.1 # single address
+192.168.1.0/30
+2001:db8::/32
+::ffff:172.16.0.0/108
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, set.Len())
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"11.0.0.1", false},
+		{"192.168.1.1", true},
+		{"192.168.1.3", true},
+		{"192.168.1.4", false},
+		{"172.16.0.1", true},
+		{"172.32.0.1", false},
+		{"::ffff:10.0.0.1", true},
+		{"2001:db8:1::1", true},
+		{"2001:db9::1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.want, set.Contains(netip.MustParseAddr(tt.ip)))
+		})
+	}
+	assert.False(t, set.Contains(netip.Addr{}))
+}
+
+func TestSet_CoveringPrefixes(t *testing.T) {
+	set := New(
+		netip.MustParsePrefix("10.1.0.0/16"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("10.2.0.0/16"),
+	)
+	assert.Equal(t, 1, set.Len())
+	assert.True(t, set.Contains(netip.MustParseAddr("10.3.0.1")))
+	all := New(netip.MustParsePrefix("0.0.0.0/0"))
+	assert.True(t, all.Contains(netip.MustParseAddr("1.2.3.4")))
+	assert.False(t, all.Contains(netip.MustParseAddr("::1")))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse(strings.NewReader("10.0.0.0/8\nnot-an-ip\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestWatch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"blocklist.txt":    {Data: []byte("10.0.0.0/8\n")},
+		"more.txt":         {Data: []byte("192.168.0.1\n")},
+		"policy.yaml":      {Data: []byte("apiVersion: v1\n")},
+		"..data/stale.txt": {Data: []byte("172.16.0.0/12\n")},
+	}
+	registry := NewRegistry()
+	_, err := registry.Get("blocklist")
+	assert.Error(t, err)
+	run, err := Watch(registry, "blocklist", fsys, 10*time.Millisecond)
+	assert.NoError(t, err)
+	set, err := registry.Get("blocklist")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, set.Len())
+	assert.False(t, set.Contains(netip.MustParseAddr("172.16.0.1")))
+	// reload on change
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fsys["more.txt"] = &fstest.MapFile{Data: []byte("192.168.0.1\n192.168.0.2\n")}
+	go run(ctx)
+	assert.Eventually(t, func() bool {
+		set, _ := registry.Get("blocklist")
+		return set.Contains(netip.MustParseAddr("192.168.0.2"))
+	}, time.Second, 10*time.Millisecond)
+}
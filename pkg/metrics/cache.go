@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	decisionCacheRequestsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authz_decision_cache_requests",
+			Help: "can be used to track the decision cache hit rate",
+		},
+		[]string{"cache", "result"},
+	)
+	decisionCacheEvictionsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authz_decision_cache_evictions",
+			Help: "can be used to track the number of decisions evicted from the cache because it was full",
+		},
+		[]string{"cache"},
+	)
+	decisionCacheInvalidationsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authz_decision_cache_invalidations",
+			Help: "can be used to track the number of times the cache was purged because policies, data documents or ip sets changed",
+		},
+		[]string{"cache"},
+	)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(decisionCacheRequestsMetric, decisionCacheEvictionsMetric, decisionCacheInvalidationsMetric)
+}
+
+// RecordDecisionCacheLookup records a decision cache lookup, result is hit, miss or bypass.
+func RecordDecisionCacheLookup(cache string, result string) {
+	decisionCacheRequestsMetric.WithLabelValues(cache, result).Inc()
+}
+
+func RecordDecisionCacheEvictions(cache string, count int) {
+	decisionCacheEvictionsMetric.WithLabelValues(cache).Add(float64(count))
+}
+
+func RecordDecisionCacheInvalidation(cache string) {
+	decisionCacheInvalidationsMetric.WithLabelValues(cache).Inc()
+}
@@ -2,26 +2,53 @@ package probes
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"os"
 
+	"github.com/kyverno/kyverno-envoy-plugin/pkg/engine/sources"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/server"
 	"github.com/kyverno/kyverno-envoy-plugin/pkg/server/handlers"
 )
 
-func NewServer(addr string) server.ServerFunc {
+// NewServer creates the probes server, the server is ready when all the checks
+// and all the sources registered in the default sources registry are.
+func NewServer(addr string, checks ...func() bool) server.ServerFunc {
+	return NewServerWithNetwork("tcp", addr, 0, checks...)
+}
+
+// NewServerWithNetwork creates the probes server listening on the given network (tcp or unix),
+// mode is the file mode of unix sockets.
+func NewServerWithNetwork(network, addr string, mode os.FileMode, checks ...func() bool) server.ServerFunc {
 	return func(ctx context.Context) error {
+		registry := sources.Default()
 		// create mux
 		mux := http.NewServeMux()
 		// register health check
 		mux.Handle("GET /livez", handlers.Healthy(True))
 		// register ready check
-		mux.Handle("GET /readyz", handlers.Ready(True))
+		mux.Handle("GET /readyz", handlers.Ready(All(append(checks, func() bool { return registry.Ready(ctx) })...)))
+		// register sources debug endpoint
+		mux.HandleFunc("GET /debug/sources", func(w http.ResponseWriter, r *http.Request) {
+			data, err := json.Marshal(registry.Statuses(r.Context()))
+			if err != nil {
+				handlers.HttpError(r.Context(), w, r, err, http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(data)
+		})
 		// create server
 		s := &http.Server{
 			Addr:    addr,
 			Handler: mux,
 		}
+		// create a listener
+		l, err := server.Listen(network, addr, mode)
+		if err != nil {
+			return err
+		}
 		// run server
-		return server.RunHttp(ctx, s, "", "")
+		return server.ServeHttp(ctx, s, l, "", "")
 	}
 }
@@ -0,0 +1,80 @@
+package probes
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// sourceInterval is the interval at which sources are reloaded to check their readiness
+const sourceInterval = 5 * time.Second
+
+// SourceReadiness reports whether a policy source is ready to serve requests.
+// The source is ready once it has been loaded successfully (errors are tolerated if some
+// policies were loaded), in strict mode any load or compilation error, or a source without
+// policies, makes it not ready until the next successful load.
+type SourceReadiness struct {
+	ready atomic.Bool
+}
+
+// Ready returns true when the source is ready.
+func (r *SourceReadiness) Ready() bool {
+	return r.ready.Load()
+}
+
+// WatchSource returns the readiness of source and the function polling it,
+// the function should be run until the context is cancelled.
+func WatchSource[DATA any](source core.Source[DATA], strict bool) (*SourceReadiness, func(context.Context)) {
+	readiness := &SourceReadiness{}
+	var lastErr string
+	var empty bool
+	check := func(ctx context.Context) {
+		policies, err := source.Load(ctx)
+		if err != nil {
+			// don't log the same error at every check
+			if err.Error() != lastErr {
+				lastErr = err.Error()
+				ctrl.LoggerFrom(ctx).Error(err, "failed to load policies", "policies", len(policies))
+			}
+			if strict {
+				readiness.ready.Store(false)
+				return
+			}
+			if len(policies) == 0 {
+				return
+			}
+		}
+		if err == nil {
+			lastErr = ""
+		}
+		// in strict mode an empty source is considered misconfigured
+		if strict && len(policies) == 0 {
+			readiness.ready.Store(false)
+			if !empty {
+				empty = true
+				ctrl.LoggerFrom(ctx).Info("no policies loaded")
+			}
+			return
+		}
+		empty = false
+		if !readiness.ready.Swap(true) {
+			ctrl.LoggerFrom(ctx).Info("policies loaded", "policies", len(policies))
+		}
+	}
+	return readiness, func(ctx context.Context) {
+		check(ctx)
+		ticker := time.NewTicker(sourceInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				check(ctx)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package probes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyverno/kyverno-envoy-plugin/sdk/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchSource(t *testing.T) {
+	failing := core.MakeSourceFunc(func(context.Context) ([]int, error) {
+		return []int{1}, errors.New("failed to compile")
+	})
+	tests := []struct {
+		name   string
+		source core.Source[int]
+		strict bool
+		want   bool
+	}{{
+		name:   "loaded",
+		source: core.MakeSource(1, 2),
+		want:   true,
+	}, {
+		name:   "loaded strict",
+		source: core.MakeSource(1, 2),
+		strict: true,
+		want:   true,
+	}, {
+		name:   "partial error",
+		source: failing,
+		want:   true,
+	}, {
+		name:   "empty",
+		source: core.MakeSource[int](),
+		want:   true,
+	}, {
+		name:   "empty strict",
+		source: core.MakeSource[int](),
+		strict: true,
+		want:   false,
+	}, {
+		name: "error",
+		source: core.MakeSourceFunc(func(context.Context) ([]int, error) {
+			return nil, errors.New("failed to load")
+		}),
+		want: false,
+	}, {
+		name:   "error strict",
+		source: failing,
+		strict: true,
+		want:   false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readiness, watcher := WatchSource(tt.source, tt.strict)
+			assert.False(t, readiness.Ready())
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			watcher(ctx)
+			assert.Equal(t, tt.want, readiness.Ready())
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+	assert.True(t, All()())
+	assert.True(t, All(True, True)())
+	assert.False(t, All(True, func() bool { return false })())
+}
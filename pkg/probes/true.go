@@ -3,3 +3,15 @@ package probes
 func True() bool {
 	return true
 }
+
+// All returns a check that succeeds when all the checks succeed.
+func All(checks ...func() bool) func() bool {
+	return func() bool {
+		for _, check := range checks {
+			if !check() {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultShards is the default number of shards of the local store.
+	DefaultShards = 64
+	// sweepInterval is the number of calls to a shard between two sweeps of idle buckets.
+	sweepInterval = 1024
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64 // tokens per nanosecond
+	burst  float64
+}
+
+// full returns true if the bucket is full at the given time, such a bucket is
+// equivalent to a new one and can be dropped.
+func (b *bucket) full(now time.Time) bool {
+	return b.tokens+float64(now.Sub(b.last))*b.rate >= b.burst
+}
+
+type shard struct {
+	lock    sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+type local struct {
+	shards []*shard
+	now    func() time.Time
+}
+
+// NewLocal returns an in-process token bucket store, buckets are spread across
+// shards to reduce lock contention.
+func NewLocal(shards int) *local {
+	return newLocal(shards, time.Now)
+}
+
+func newLocal(shards int, now func() time.Time) *local {
+	if shards < 1 {
+		shards = 1
+	}
+	s := &local{
+		shards: make([]*shard, shards),
+		now:    now,
+	}
+	for i := range s.shards {
+		s.shards[i] = &shard{
+			buckets: map[string]*bucket{},
+		}
+	}
+	return s
+}
+
+func (s *local) Allow(key string, limit int64, period time.Duration, burst int64) Result {
+	if limit <= 0 || period <= 0 || burst <= 0 {
+		return Result{Limit: max(burst, 0)}
+	}
+	// buckets with different settings are tracked separately
+	key = fmt.Sprintf("%s/%d/%d/%d", key, limit, period, burst)
+	shard := s.shard(key)
+	now := s.now()
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	shard.sweep(now)
+	b := shard.buckets[key]
+	if b == nil {
+		b = &bucket{
+			tokens: float64(burst),
+			last:   now,
+			rate:   float64(limit) / float64(period),
+			burst:  float64(burst),
+		}
+		shard.buckets[key] = b
+	}
+	// refill
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+float64(elapsed)*b.rate)
+		b.last = now
+	}
+	result := Result{
+		Limit: burst,
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) / b.rate))
+	}
+	result.Remaining = int64(math.Floor(b.tokens))
+	result.Reset = time.Duration(math.Ceil((b.burst - b.tokens) / b.rate))
+	return result
+}
+
+func (s *local) shard(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key)) //nolint:errcheck
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// sweep periodically drops full buckets, must be called with the shard lock held.
+func (s *shard) sweep(now time.Time) {
+	s.calls++
+	if s.calls < sweepInterval {
+		return
+	}
+	s.calls = 0
+	for key, b := range s.buckets {
+		if b.full(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestLocal_Allow(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	s := newLocal(4, c.Now)
+	// burst of 3, refill 1 token per second
+	for i := range 3 {
+		r := s.Allow("alice", 1, time.Second, 3)
+		assert.True(t, r.Allowed)
+		assert.Equal(t, int64(3), r.Limit)
+		assert.Equal(t, int64(2-i), r.Remaining)
+		assert.Zero(t, r.RetryAfter)
+	}
+	// bucket is empty
+	r := s.Allow("alice", 1, time.Second, 3)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, int64(0), r.Remaining)
+	assert.Equal(t, time.Second, r.RetryAfter)
+	assert.Equal(t, 3*time.Second, r.Reset)
+	// other keys are not affected
+	assert.True(t, s.Allow("bob", 1, time.Second, 3).Allowed)
+	// half a second later, still empty
+	c.now = c.now.Add(500 * time.Millisecond)
+	r = s.Allow("alice", 1, time.Second, 3)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, 500*time.Millisecond, r.RetryAfter)
+	// one more token
+	c.now = c.now.Add(500 * time.Millisecond)
+	assert.True(t, s.Allow("alice", 1, time.Second, 3).Allowed)
+	assert.False(t, s.Allow("alice", 1, time.Second, 3).Allowed)
+	// fully refilled, never more than burst
+	c.now = c.now.Add(time.Hour)
+	r = s.Allow("alice", 1, time.Second, 3)
+	assert.True(t, r.Allowed)
+	assert.Equal(t, int64(2), r.Remaining)
+}
+
+func TestLocal_AllowInvalid(t *testing.T) {
+	s := NewLocal(DefaultShards)
+	assert.False(t, s.Allow("alice", 0, time.Second, 1).Allowed)
+	assert.False(t, s.Allow("alice", 1, 0, 1).Allowed)
+	assert.False(t, s.Allow("alice", 1, time.Second, 0).Allowed)
+}
+
+func TestLocal_Sweep(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	s := newLocal(1, c.Now)
+	s.Allow("alice", 1, time.Second, 1)
+	c.now = c.now.Add(time.Minute)
+	for range sweepInterval {
+		s.Allow("bob", 1, time.Second, 1)
+	}
+	assert.Len(t, s.shards[0].buckets, 1)
+}
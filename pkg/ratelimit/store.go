@@ -0,0 +1,32 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Result is the outcome of a rate limit check.
+type Result struct {
+	// Allowed is true when a token was available.
+	Allowed bool
+	// Limit is the bucket capacity (burst).
+	Limit int64
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int64
+	// RetryAfter is the time to wait before a token becomes available (zero when allowed).
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store is a rate limit backend.
+// It consumes one token from the bucket identified by key, the bucket refills
+// at limit tokens per period and holds at most burst tokens.
+type Store interface {
+	Allow(key string, limit int64, period time.Duration, burst int64) Result
+}
+
+// Default returns the process wide local store.
+var Default = sync.OnceValue(func() Store {
+	return NewLocal(DefaultShards)
+})
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthInterval is the interval at which the serving status is updated
+const healthInterval = time.Second
+
+// HealthServer implements grpc.health.v1, both the server ("") and the given
+// services report SERVING only when the server is ready.
+type HealthServer struct {
+	*health.Server
+	ready    func() bool
+	services []string
+}
+
+func NewHealthServer(ready func() bool, services ...string) *HealthServer {
+	s := &HealthServer{
+		Server:   health.NewServer(),
+		ready:    ready,
+		services: services,
+	}
+	s.update()
+	return s
+}
+
+func (s *HealthServer) update() {
+	status := healthgrpc.HealthCheckResponse_SERVING
+	if s.ready != nil && !s.ready() {
+		status = healthgrpc.HealthCheckResponse_NOT_SERVING
+	}
+	s.SetServingStatus("", status)
+	for _, service := range s.services {
+		s.SetServingStatus(service, status)
+	}
+}
+
+// Run updates the serving status until ctx is done.
+func (s *HealthServer) Run(ctx context.Context) {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// report NOT_SERVING while shutting down
+			s.Shutdown()
+			return
+		case <-ticker.C:
+			s.update()
+		}
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"time"
 
@@ -12,8 +13,26 @@ import (
 )
 
 func RunHttp(ctx context.Context, server *http.Server, certFile, keyFile string) error {
+	addr := server.Addr
+	if addr == "" {
+		if certFile != "" && keyFile != "" {
+			addr = ":https"
+		} else {
+			addr = ":http"
+		}
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return ServeHttp(ctx, server, listener, certFile, keyFile)
+}
+
+// ServeHttp serves server on listener until the context is cancelled, the listener is closed when the function returns.
+func ServeHttp(ctx context.Context, server *http.Server, listener net.Listener, certFile, keyFile string) error {
 	logger := ctrl.LoggerFrom(ctx).
-		WithValues("address", server.Addr).
+		WithValues("address", listener.Addr()).
+		WithValues("network", listener.Addr().Network()).
 		WithValues("cert", certFile).
 		WithValues("key", keyFile)
 	defer logger.Info("HTTP Server stopped")
@@ -44,10 +63,10 @@ func RunHttp(ctx context.Context, server *http.Server, certFile, keyFile string)
 			logger.Info("HTTP Server starting...")
 			if certFile != "" && keyFile != "" {
 				// server over https
-				return server.ListenAndServeTLS(certFile, keyFile)
+				return server.ServeTLS(listener, certFile, keyFile)
 			} else {
 				// server over http
-				return server.ListenAndServe()
+				return server.Serve(listener)
 			}
 		}
 		// server closed is not an error
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+// Listen announces on the local network address, for unix sockets a stale socket file left by a previous
+// run is removed and the socket file mode is set to mode when not zero. Addresses starting with @ denote
+// linux abstract sockets, they have no file.
+func Listen(network, address string, mode os.FileMode) (net.Listener, error) {
+	unix := network == "unix" || network == "unixpacket"
+	abstract := strings.HasPrefix(address, "@")
+	if unix && !abstract {
+		if info, err := os.Lstat(address); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				return nil, fmt.Errorf("failed to listen on %s: file exists and is not a socket", address)
+			}
+			if err := os.Remove(address); err != nil {
+				return nil, err
+			}
+		}
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	if unix && !abstract && mode != 0 {
+		if err := os.Chmod(address, mode); err != nil {
+			return nil, multierr.Combine(err, listener.Close())
+		}
+	}
+	return listener, nil
+}
+
+// ParseFileMode parses an octal file mode like 0660, an empty string returns zero.
+func ParseFileMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0o777 {
+		return 0, fmt.Errorf("invalid file mode %q, expected an octal permission like 0660", mode)
+	}
+	return os.FileMode(value), nil
+}
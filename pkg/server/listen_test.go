@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authz.sock")
+	l, err := Listen("unix", path, 0o600)
+	assert.NoError(t, err)
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	// a stale socket is replaced
+	stale, err := net.Listen("unix", filepath.Join(t.TempDir(), "stale.sock"))
+	assert.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	assert.NoError(t, stale.Close())
+	l2, err := Listen("unix", stale.Addr().String(), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, l2.Close())
+	assert.NoError(t, l.Close())
+	// the socket file is removed on close
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestListenNotASocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(path, nil, 0o600))
+	_, err := Listen("unix", path, 0)
+	assert.Error(t, err)
+}
+
+func TestParseFileMode(t *testing.T) {
+	mode, err := ParseFileMode("0660")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o660), mode)
+	mode, err = ParseFileMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0), mode)
+	_, err = ParseFileMode("rw")
+	assert.Error(t, err)
+	_, err = ParseFileMode("7777")
+	assert.Error(t, err)
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kyverno/pkg/ext/wildcard"
+)
+
+// reloadInterval is the minimum interval between two checks of the files on disk
+const reloadInterval = time.Second
+
+var cipherSuites = []uint16{
+	// AEADs w/ ECDHE
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// NewTLSConfig returns a server tls config, the certificate and the client CA bundle are reloaded
+// from disk when they change.
+// When clientCAFile is set, clients must present a certificate signed by one of the CAs (mutual TLS).
+// When clientSANs is not empty, the client certificate must contain at least one SAN (DNS name, URI,
+// email address or IP address) matching one of the patterns (wildcards are supported).
+func NewTLSConfig(certFile, keyFile, clientCAFile string, clientSANs []string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("both a certificate and a key file are required")
+	}
+	if clientCAFile == "" && len(clientSANs) != 0 {
+		return nil, errors.New("allowed client SANs require a client CA file")
+	}
+	certificate := newReloader(func() (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &cert, nil
+	}, certFile, keyFile)
+	// fail early if the certificate can't be loaded
+	if _, err := certificate.get(); err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: cipherSuites,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certificate.get()
+		},
+	}
+	if clientCAFile == "" {
+		return config, nil
+	}
+	clientCAs := newReloader(func() (*x509.CertPool, error) {
+		data, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", clientCAFile)
+		}
+		return pool, nil
+	}, clientCAFile)
+	if _, err := clientCAs.get(); err != nil {
+		return nil, fmt.Errorf("failed to load client CA: %w", err)
+	}
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		pool, err := clientCAs.get()
+		if err != nil {
+			return nil, err
+		}
+		clone := config.Clone()
+		clone.GetConfigForClient = nil
+		clone.ClientCAs = pool
+		return clone, nil
+	}
+	if len(clientSANs) != 0 {
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("client certificate is required")
+			}
+			if !matchSANs(state.PeerCertificates[0], clientSANs) {
+				return errors.New("client certificate SANs are not allowed")
+			}
+			return nil
+		}
+	}
+	return config, nil
+}
+
+func matchSANs(cert *x509.Certificate, patterns []string) bool {
+	var sans []string
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	for _, pattern := range patterns {
+		for _, san := range sans {
+			if wildcard.Match(pattern, san) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reloader caches a value loaded from files and reloads it when one of the files changes.
+// If reloading fails the previous value is kept.
+type reloader[T any] struct {
+	lock     sync.Mutex
+	load     func() (T, error)
+	files    []string
+	checked  time.Time
+	modTimes []time.Time
+	value    T
+	hasValue bool
+}
+
+func newReloader[T any](load func() (T, error), files ...string) *reloader[T] {
+	return &reloader[T]{
+		load:     load,
+		files:    files,
+		modTimes: make([]time.Time, len(files)),
+	}
+}
+
+func (r *reloader[T]) get() (T, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	if r.hasValue && now.Sub(r.checked) < reloadInterval {
+		return r.value, nil
+	}
+	r.checked = now
+	changed := !r.hasValue
+	modTimes := make([]time.Time, len(r.files))
+	for i, file := range r.files {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[i] = info.ModTime()
+		}
+		if !modTimes[i].Equal(r.modTimes[i]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return r.value, nil
+	}
+	value, err := r.load()
+	if err != nil {
+		if r.hasValue {
+			return r.value, nil
+		}
+		return value, err
+	}
+	r.value, r.hasValue, r.modTimes = value, true, modTimes
+	return r.value, nil
+}
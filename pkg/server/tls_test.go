@@ -0,0 +1,177 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writePEM(t *testing.T, path string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	require.NoError(t, os.WriteFile(path+".crt", data, 0o600))
+	if key != nil {
+		der, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+		require.NoError(t, os.WriteFile(path+".key", data, 0o600))
+	}
+}
+
+func handshake(t *testing.T, config *tls.Config, client *tls.Config) error {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	errs := make(chan error, 1)
+	go func() {
+		conn := tls.Server(serverConn, config)
+		errs <- conn.Handshake()
+		conn.Close()
+	}()
+	conn := tls.Client(clientConn, client)
+	if err := conn.Handshake(); err == nil {
+		// with TLS 1.3 the client completes the handshake before the server verified its certificate
+		_, _ = conn.Read(make([]byte, 1))
+	}
+	return <-errs
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	writePEM(t, filepath.Join(dir, "ca"), ca, nil)
+	server, serverKey := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		DNSNames:    []string{"server"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	writePEM(t, filepath.Join(dir, "server"), server, serverKey)
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/default/sa/envoy")
+	client, clientKey := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		URIs:        []*url.URL{spiffe},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	clientCert := tls.Certificate{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey}
+	certFile, keyFile, caFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt")
+	tests := []struct {
+		name       string
+		clientCA   string
+		clientSANs []string
+		clientCert bool
+		wantErr    bool
+	}{{
+		name: "tls",
+	}, {
+		name:       "mtls",
+		clientCA:   caFile,
+		clientCert: true,
+	}, {
+		name:     "mtls without client certificate",
+		clientCA: caFile,
+		wantErr:  true,
+	}, {
+		name:       "mtls with allowed san",
+		clientCA:   caFile,
+		clientSANs: []string{"spiffe://cluster.local/ns/default/sa/*"},
+		clientCert: true,
+	}, {
+		name:       "mtls with denied san",
+		clientCA:   caFile,
+		clientSANs: []string{"spiffe://cluster.local/ns/other/sa/*"},
+		clientCert: true,
+		wantErr:    true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewTLSConfig(certFile, keyFile, tt.clientCA, tt.clientSANs)
+			require.NoError(t, err)
+			clientConfig := &tls.Config{RootCAs: pool, ServerName: "server"}
+			if tt.clientCert {
+				clientConfig.Certificates = []tls.Certificate{clientCert}
+			}
+			err = handshake(t, config, clientConfig)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	_, err := NewTLSConfig("", "", "", nil)
+	assert.Error(t, err)
+	_, err = NewTLSConfig("missing.crt", "missing.key", "", nil)
+	assert.Error(t, err)
+	_, err = NewTLSConfig("missing.crt", "missing.key", "", []string{"*"})
+	assert.Error(t, err)
+}
+
+func TestReloader(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, []byte("a"), 0o600))
+	r := newReloader(func() (string, error) {
+		data, err := os.ReadFile(file)
+		return string(data), err
+	}, file)
+	value, err := r.get()
+	require.NoError(t, err)
+	assert.Equal(t, "a", value)
+	require.NoError(t, os.WriteFile(file, []byte("b"), 0o600))
+	require.NoError(t, os.Chtimes(file, time.Now(), time.Now().Add(time.Minute)))
+	// not reloaded before the reload interval
+	value, err = r.get()
+	require.NoError(t, err)
+	assert.Equal(t, "a", value)
+	r.checked = time.Time{}
+	value, err = r.get()
+	require.NoError(t, err)
+	assert.Equal(t, "b", value)
+	// failures keep the previous value
+	require.NoError(t, os.Remove(file))
+	r.checked = time.Time{}
+	value, err = r.get()
+	require.NoError(t, err)
+	assert.Equal(t, "b", value)
+}